@@ -1,11 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/go-playground/validator/v10"
+	html_parser "github.com/nxtcoder17/htmlc/pkg/parser/html"
+	template_parser "github.com/nxtcoder17/htmlc/pkg/parser/template"
+	"github.com/nxtcoder17/htmlc/pkg/types"
 	"sigs.k8s.io/yaml"
 )
 
@@ -13,26 +19,161 @@ type Config struct {
 	WorkingDir string
 
 	Components []Components `json:"components"`
-	Pages      Pages        `json:"pages,omitempty"`
+	Pages      PageSets     `json:"pages,omitempty" validate:"dive"`
+	Layouts    *Layouts     `json:"layouts,omitempty"`
+	Export     *Export      `json:"export,omitempty"`
+	Schema     *Schema      `json:"schema,omitempty"`
+	Elements   *Elements    `json:"elements,omitempty"`
+
+	// Strict reports component attributes, that are not declared with `@param` (unless component renders `{{.props}}`),
+	// or do not match their declared type
+	Strict bool `json:"strict,omitempty"`
+
+	// MaxDepth is the maximum nesting of components, i.e. 2 for a page using Card, that uses CardBody. When 0, html
+	// components are not limited, and text components are limited to 100, as a text component could use itself
+	MaxDepth int `json:"maxDepth,omitempty" validate:"gte=0"`
+}
+
+// PageSets are the page sets, every one of them is generated into its own output dir (and go package),
+// a single page set could also be configured as an object, in place of a list
+type PageSets []Pages
+
+func (ps *PageSets) UnmarshalJSON(b []byte) error {
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '{' {
+		var p Pages
+		if err := json.Unmarshal(b, &p); err != nil {
+			return err
+		}
+		*ps = PageSets{p}
+		return nil
+	}
 
-	generatorDir string
+	var pages []Pages
+	if err := json.Unmarshal(b, &pages); err != nil {
+		return err
+	}
+	*ps = pages
+	return nil
 }
 
 type Pages struct {
+	// Type is either html (default), or text for pages like email bodies, config files, markdown, they use
+	// text components, with `{{ component "Name" "key" .value }}`, and are not HTML escaped
+	Type template_parser.TemplateType `json:"type,omitempty" validate:"omitempty,oneof=html text"`
+
 	Input  string `json:"input"`
 	Output struct {
 		Package string `json:"pkg" validate:"required"`
 		Dir     string `json:"dir" validate:"required"`
 		Go      bool   `json:"go,omitempty"`
 	} `json:"output" validate:"required"`
+
+	// Delims are the go template delimiters, pages (and layouts) use, i.e. ["[[", "]]"], default is ["{{", "}}"]
+	Delims []string `json:"delims,omitempty" validate:"omitempty,len=2"`
+
+	// Patterns must follow [guidelines](https://pkg.go.dev/path/filepath#Match), default is ["*.html"] for html pages,
+	// and ["*.txt", "*.md", "*.tmpl"] for text pages
+	Patterns []string `json:"patterns,omitempty"`
+
+	// Components are the names of components, pages could use, default is all of them
+	Components []string `json:"components,omitempty"`
+
+	// Prefix is the URL path, pages are served at with `htmlc serve`, and the directory they are exported into
+	// with `htmlc export`, i.e. /admin
+	Prefix string `json:"prefix,omitempty"`
 }
 
 type Components struct {
+	// Name is used by page sets, to pick their components
+	Name string `json:"name,omitempty"`
+
 	Dir string `json:"dir" validate:"required"`
 
+	// Namespace is the prefix, pages use components of Dir with, i.e. ui for `<ui:Button>`, and
+	// `{{ component "ui:Button" }}` in text pages. Components without a namespace are used as is, i.e. `<Button>`
+	Namespace string `json:"namespace,omitempty" validate:"omitempty,alphanum"`
+
+	// Type is either html (default), or text for components used by text pages
+	Type template_parser.TemplateType `json:"type,omitempty" validate:"omitempty,oneof=html text"`
+
 	// Patterns must follow [guidelines](https://pkg.go.dev/path/filepath#Match)
 	// htmlc does recursive matching of patterns
 	Patterns []string `json:"patterns"`
+
+	// Delims are the go template delimiters, components in Dir use, i.e. ["[[", "]]"], default is ["{{", "}}"]
+	Delims []string `json:"delims,omitempty" validate:"omitempty,len=2"`
+}
+
+// Layouts are the page layouts, a page uses one with `<Layout name="base">`, where name is
+// the layout file path relative to Dir, without extension
+type Layouts struct {
+	Dir string `json:"dir" validate:"required"`
+
+	// Patterns must follow [guidelines](https://pkg.go.dev/path/filepath#Match)
+	Patterns []string `json:"patterns"`
+}
+
+// Export configures `htmlc export`, it builds a static site into Dir, with fully rendered pages,
+// and Assets directory copied into it
+type Export struct {
+	Dir    string `json:"dir" validate:"required"`
+	Assets string `json:"assets,omitempty"`
+}
+
+// Schema makes `htmlc generate` write JSON Schema of every component's attributes (<Component>.schema.json),
+// and a typescript definitions file (components.d.ts) with all of them, into Dir
+type Schema struct {
+	Dir string `json:"dir" validate:"required"`
+}
+
+// pagesComponents returns the components, that page set pages uses
+func (cfg *Config) pagesComponents(pages *Pages) ([]Components, error) {
+	if len(pages.Components) == 0 {
+		return cfg.Components, nil
+	}
+
+	var result []Components
+	for _, name := range pages.Components {
+		idx := slices.IndexFunc(cfg.Components, func(c Components) bool { return c.Name == name })
+		if idx == -1 {
+			return nil, fmt.Errorf("pages (%s): unknown components (%s)", pages.Input, name)
+		}
+		result = append(result, cfg.Components[idx])
+	}
+
+	return result, nil
+}
+
+// isText reports whether t is the text template type
+func isText(t template_parser.TemplateType) bool {
+	return t == template_parser.Text
+}
+
+// Elements decides, which tags in pages (and components) are kept as is, instead of being used as components.
+// html, svg and mathml elements, and custom elements (i.e. <sl-button>) are kept as is by default, unless they are
+// written like a component (i.e. <Menu>), of a registered one
+type Elements struct {
+	// Allow are patterns of tags (i.e. "x-*", or "mjml"), that are kept as is
+	Allow []string `json:"allow,omitempty"`
+
+	// Deny are patterns of tags (i.e. "ui-*"), that are components, even if they are custom elements
+	Deny []string `json:"deny,omitempty"`
+}
+
+// toElements converts configured elements, into html parser's elements
+func toElements(e *Elements) html_parser.Elements {
+	if e == nil {
+		return html_parser.Elements{}
+	}
+	return html_parser.Elements{Allow: e.Allow, Deny: e.Deny}
+}
+
+// toDelims converts configured delims, into template delimiters
+func toDelims(delims []string) types.Delims {
+	if len(delims) != 2 {
+		return types.Delims{}
+	}
+	return types.Delims{Left: delims[0], Right: delims[1]}
 }
 
 func ConfigFromFile(file string) (*Config, error) {
@@ -63,7 +204,5 @@ func ConfigFromFile(file string) (*Config, error) {
 
 	cfg.WorkingDir = s
 
-	cfg.generatorDir = cfg.Pages.Output.Dir + ".tt"
-
 	return &cfg, nil
 }
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmlTemplate "html/template"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	textTemplate "text/template"
+
+	"github.com/nxtcoder17/htmlc/pkg/funcs"
+	"github.com/nxtcoder17/htmlc/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+// dataFileExts are the extensions of a page's data file, looked up (in order) next to the page,
+// i.e. pages/users/register.html has its data in pages/users/register.json
+var dataFileExts = []string{".json", ".yaml", ".yml"}
+
+// staticOutput writes fully rendered pages into a directory, with their `{{ }}` expressions executed
+// against page's data file
+type staticOutput struct {
+	dir      string
+	pagesDir string
+
+	// delims are the go template delimiters, pages use
+	delims types.Delims
+
+	// text is true for text pages, they are rendered without HTML escaping
+	text bool
+}
+
+// pageData reads data file of page (entry) in pagesDir, a page without any data file has no data
+func pageData(pagesDir string, entry string) (map[string]any, error) {
+	base := filepath.Join(pagesDir, strings.TrimSuffix(entry, filepath.Ext(entry)))
+
+	for _, ext := range dataFileExts {
+		b, err := os.ReadFile(base + ext)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		var data map[string]any
+		if err := yaml.Unmarshal(b, &data); err != nil {
+			return nil, fmt.Errorf("%s: %w", base+ext, err)
+		}
+		return data, nil
+	}
+
+	return nil, nil
+}
+
+// renderPage executes generated page (entry) b, with its data file as `.`, the `{{ define }}` blocks of the page
+// are only rendered, when the page uses them
+func renderPage(pagesDir string, entry string, b []byte, delims types.Delims, text bool) ([]byte, error) {
+	data, err := pageData(pagesDir, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	d := delims.OrDefault()
+
+	var execute func(w io.Writer, data any) error
+	if text {
+		t, err := textTemplate.New(entry).Delims(d.Left, d.Right).Funcs(funcs.FuncMap()).Parse(string(b))
+		if err != nil {
+			return nil, err
+		}
+		execute = t.Execute
+	} else {
+		t, err := htmlTemplate.New(entry).Delims(d.Left, d.Right).Funcs(funcs.FuncMap()).Parse(string(b))
+		if err != nil {
+			return nil, err
+		}
+		execute = t.Execute
+	}
+
+	out := new(bytes.Buffer)
+	if err := execute(out, data); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func (s *staticOutput) WritePage(entry string, b []byte) error {
+	out, err := renderPage(s.pagesDir, entry, b, s.delims, s.text)
+	if err != nil {
+		return prefixErrors(filepath.Join(s.pagesDir, entry), err)
+	}
+
+	output := filepath.Join(s.dir, entry)
+	if err := os.MkdirAll(filepath.Dir(output), 0o766); err != nil {
+		return err
+	}
+
+	return os.WriteFile(output, out, 0o644)
+}
+
+func (s *staticOutput) RemovePage(entry string) error {
+	if err := os.Remove(filepath.Join(s.dir, entry)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Reset does nothing, as exporter cleans the export dir, before any page set is exported
+func (s *staticOutput) Reset() error {
+	return nil
+}
+
+// prefixErrors prefixes every error in err (which could be joined errors) with file
+func prefixErrors(file string, err error) error {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, prefixErrors(file, e))
+	}
+	return errors.Join(errs...)
+}
+
+// copyDir copies all the files in src, into dst
+func copyDir(src string, dst string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o766)
+		}
+
+		in, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		_, err = io.Copy(out, in)
+		return err
+	})
+}
+
+// checkExportDir reports an export dir, that is the project dir, or has any of the inputs (pages, components,
+// layouts or assets) within it, as exporter removes the export dir
+func checkExportDir(cfg *Config) error {
+	inputs := []string{cfg.WorkingDir, cfg.Export.Assets}
+	for _, pages := range cfg.Pages {
+		inputs = append(inputs, pages.Input)
+	}
+	for _, c := range cfg.Components {
+		inputs = append(inputs, c.Dir)
+	}
+	if cfg.Layouts != nil {
+		inputs = append(inputs, cfg.Layouts.Dir)
+	}
+
+	for _, input := range inputs {
+		if input == "" {
+			continue
+		}
+
+		if rel, err := filepath.Rel(cfg.Export.Dir, input); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("invalid export dir (%s), it would remove (%s) on export", cfg.Export.Dir, input)
+		}
+	}
+
+	return nil
+}
+
+// exporter builds a deployable static site, i.e. rendered pages along with the assets
+func exporter(cfg *Config) error {
+	sanitizeConfig(cfg)
+
+	if cfg.Export == nil {
+		return fmt.Errorf("export is not configured, add `export.dir` to htmlc.yml")
+	}
+
+	if err := checkExportDir(cfg); err != nil {
+		return err
+	}
+
+	ps, err := newPageSets(cfg, func(pages *Pages) pagesOutput {
+		return &staticOutput{
+			dir:      filepath.Join(cfg.Export.Dir, filepath.FromSlash(pages.Prefix)),
+			pagesDir: pages.Input,
+			delims:   toDelims(pages.Delims),
+			text:     isText(pages.Type),
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := ps.parseComponents(); err != nil {
+		return err
+	}
+
+	// INFO: page sets share the export dir, so it is cleaned once, instead of by every page set
+	if err := os.RemoveAll(cfg.Export.Dir); err != nil {
+		return err
+	}
+
+	slog.Info("exporting pages", "dir", cfg.Export.Dir)
+	if err := ps.generatePages(); err != nil {
+		return err
+	}
+
+	if cfg.Export.Assets == "" {
+		return nil
+	}
+
+	dst := filepath.Join(cfg.Export.Dir, filepath.Base(cfg.Export.Assets))
+	slog.Info("copying assets", "from", cfg.Export.Assets, "to", dst)
+	return copyDir(cfg.Export.Assets, dst)
+}
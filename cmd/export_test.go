@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes files (by their path, relative to dir) into dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExporter(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"htmlc.yml": `components:
+  - dir: ./components
+pages:
+  - input: ./pages
+    output:
+      dir: ./generated/pages
+      pkg: pages
+export:
+  dir: ./dist
+`,
+		"components/Item.html": `{{- define "Item" }}<li>{{ .label }}</li>{{- end }}`,
+		"pages/defs.html": `{{ define "row" }}<Item label="{{ . }}" />{{ end }}
+<ul>{{ range .Items }}{{ template "row" . }}{{ end }}</ul>`,
+		"pages/defs.yml": "Items: [a, b]\nExtra: hi\n",
+	})
+
+	cfg, err := ConfigFromFile(filepath.Join(dir, "htmlc.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := exporter(cfg); err != nil {
+		t.Fatalf("exporter() error = %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "dist", "defs.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := string(b); !strings.Contains(got, "<ul><li>a</li><li>b</li></ul>") || strings.Contains(got, "Extra") {
+		t.Errorf("exported page = %q, want it to render its content, with data as is", got)
+	}
+}
+
+func TestExporter_exportDir(t *testing.T) {
+	tests := []struct {
+		dir     string
+		wantErr bool
+	}{
+		{dir: "./dist", wantErr: false},
+		{dir: ".", wantErr: true},
+		{dir: "..", wantErr: true},
+		{dir: "./pages", wantErr: true},
+		{dir: "./components", wantErr: true},
+		{dir: "./layouts", wantErr: true},
+		{dir: "./static", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "project")
+
+			writeFiles(t, dir, map[string]string{
+				"htmlc.yml": `components:
+  - dir: ./components
+pages:
+  - input: ./pages
+    output:
+      dir: ./generated/pages
+      pkg: pages
+layouts:
+  dir: ./layouts
+export:
+  dir: ` + tt.dir + `
+  assets: ./static
+`,
+				"components/Item.html": `{{- define "Item" }}<li>{{ .label }}</li>{{- end }}`,
+				"pages/index.html":     `<p>hi</p>`,
+				"layouts/base.html":    `<html><body><slot /></body></html>`,
+				"static/style.css":     `p {}`,
+			})
+
+			cfg, err := ConfigFromFile(filepath.Join(dir, "htmlc.yml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = exporter(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("exporter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			for _, input := range []string{"htmlc.yml", "pages/index.html", "components/Item.html", "layouts/base.html", "static/style.css"} {
+				if _, err := os.Stat(filepath.Join(dir, input)); err != nil {
+					t.Errorf("input (%s) is removed, got %v", input, err)
+				}
+			}
+		})
+	}
+}
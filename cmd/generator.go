@@ -0,0 +1,405 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	fn "github.com/nxtcoder17/htmlc/pkg/functions"
+	html_parser "github.com/nxtcoder17/htmlc/pkg/parser/html"
+	template_parser "github.com/nxtcoder17/htmlc/pkg/parser/template"
+	text_parser "github.com/nxtcoder17/htmlc/pkg/parser/text"
+	"github.com/nxtcoder17/htmlc/pkg/types"
+)
+
+var (
+	pagesPatterns     = []string{"*.html"}
+	textPagesPatterns = []string{"*.txt", "*.md", "*.tmpl"}
+)
+
+// pagesOutput is where generated pages are written to
+type pagesOutput interface {
+	WritePage(entry string, b []byte) error
+	RemovePage(entry string) error
+	// Reset removes all the generated pages
+	Reset() error
+}
+
+// dirOutput writes generated pages into a directory
+type dirOutput struct {
+	dir string
+}
+
+func (d *dirOutput) WritePage(entry string, b []byte) error {
+	output := filepath.Join(d.dir, entry)
+	if err := os.MkdirAll(filepath.Dir(output), 0o766); err != nil {
+		return err
+	}
+
+	return os.WriteFile(output, b, 0o644)
+}
+
+func (d *dirOutput) RemovePage(entry string) error {
+	output := filepath.Join(d.dir, entry)
+	if err := os.Remove(output); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.Remove(output + "_generated.go"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (d *dirOutput) Reset() error {
+	return os.RemoveAll(d.dir)
+}
+
+// pagesGenerator generates pages of a page set
+type pagesGenerator struct {
+	cfg        *Config
+	pages      *Pages
+	components *template_parser.Components
+	output     pagesOutput
+
+	// textComponents are the components of `type: text` component dirs, used by text pages
+	textComponents *template_parser.Components
+
+	// goParser is nil, when pages.output.go is not enabled, or pages are not being written to pages output dir
+	goParser *template_parser.Parser
+
+	// usedComponents maps a page (relative to pages input dir), to (lowercased) names of all the components
+	// it uses, either directly or via other components
+	usedComponents map[string]*types.Set[string]
+}
+
+// newPagesGenerator creates a generator of page set pages, that writes into output, and into page set's output dir
+// when output is nil
+func newPagesGenerator(cfg *Config, pages *Pages, output pagesOutput) (*pagesGenerator, error) {
+	g := &pagesGenerator{
+		cfg:            cfg,
+		pages:          pages,
+		components:     template_parser.NewComponents(),
+		textComponents: template_parser.NewTextComponents(),
+		output:         output,
+		usedComponents: make(map[string]*types.Set[string]),
+	}
+
+	if output != nil {
+		return g, nil
+	}
+
+	g.output = &dirOutput{dir: pages.Output.Dir}
+
+	if pages.Output.Go {
+		ttype := template_parser.Html
+		if isText(pages.Type) {
+			ttype = template_parser.Text
+		}
+
+		p, err := template_parser.NewParser(ttype)
+		if err != nil {
+			return nil, err
+		}
+		g.goParser = p
+	}
+
+	return g, nil
+}
+
+// parseComponents (re)parses all components of the page set, existing components are replaced only if all of them
+// parse successfully
+func (g *pagesGenerator) parseComponents() error {
+	dirs, err := g.cfg.pagesComponents(g.pages)
+	if err != nil {
+		return err
+	}
+
+	components, textComponents, err := parseComponents(g.cfg, dirs)
+	if err != nil {
+		return err
+	}
+
+	g.components = components
+	g.textComponents = textComponents
+	return nil
+}
+
+// parseComponents parses components in dirs, into html and text components
+func parseComponents(cfg *Config, dirs []Components) (*template_parser.Components, *template_parser.Components, error) {
+	components := template_parser.NewComponents()
+	components.Strict = cfg.Strict
+
+	textComponents := template_parser.NewTextComponents()
+	textComponents.Strict = cfg.Strict
+	textComponents.MaxDepth = cfg.MaxDepth
+
+	var errs []error
+	for _, tc := range dirs {
+		c := components
+		if isText(tc.Type) {
+			c = textComponents
+		}
+
+		c.Delims = toDelims(tc.Delims)
+		c.Namespace = tc.Namespace
+		if err := c.ParseDir(tc.Dir, tc.Patterns); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+
+	return components, textComponents, nil
+}
+
+// fileComponents returns (lowercased) names of components, defined in file, either html or text
+func (g *pagesGenerator) fileComponents(file string) []string {
+	return append(g.components.FileComponents(file), g.textComponents.FileComponents(file)...)
+}
+
+// writeSchemas writes JSON Schema, and typescript definitions of all the components, when schema is configured
+func writeSchemas(cfg *Config) error {
+	if cfg.Schema == nil {
+		return nil
+	}
+
+	components, textComponents, err := parseComponents(cfg, cfg.Components)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("writing component schemas", "dir", cfg.Schema.Dir)
+	return template_parser.WriteSchemas(cfg.Schema.Dir, "components", append(components.Structs(), textComponents.Structs()...))
+}
+
+func (g *pagesGenerator) generatePage(entry string) error {
+	input := filepath.Join(g.pages.Input, entry)
+
+	in, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	used := types.NewSet[string]()
+	g.usedComponents[entry] = used
+
+	out := new(bytes.Buffer)
+
+	if isText(g.pages.Type) {
+		err = g.expandTextPage(input, in, out, used)
+	} else {
+		err = g.expandPage(input, in, out, used)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := g.output.WritePage(entry, out.Bytes()); err != nil {
+		return err
+	}
+
+	if g.goParser == nil {
+		return nil
+	}
+
+	structNamePrefix := "page"
+	route := template_parser.PageRoute(entry)
+	return g.goParser.ParseFile(g.pages.Output.Dir, entry, g.pages.Output.Dir, g.pages.Output.Package, template_parser.ParseOptions{
+		StructNamePrefix:        &structNamePrefix,
+		GeneratingForComponents: false,
+		Route:                   &route,
+		Delims:                  toDelims(g.pages.Delims),
+	})
+}
+
+// expandPage expands components, and layout of an html page, recording all of them into used
+func (g *pagesGenerator) expandPage(input string, in io.Reader, out io.Writer, used *types.Set[string]) error {
+	elements := toElements(g.cfg.Elements)
+	elements.Registered = g.components.HasComponent
+
+	return html_parser.Parse(html_parser.Params{
+		FileName: input,
+		Input:    in,
+		Output:   out,
+		Template: g.components.Template,
+		GetComponent: func(name string, attrs map[string]any) (html_parser.Component, error) {
+			used.Add(template_parser.LookupName(name))
+			return g.components.GetComponent(name, attrs)
+		},
+		GetLayout: func(name string) (string, io.Reader, error) {
+			used.Add(layoutDependency(name))
+			return g.getLayout(name)
+		},
+		Delims:   toDelims(g.pages.Delims),
+		Elements: elements,
+		MaxDepth: g.cfg.MaxDepth,
+	})
+}
+
+// expandTextPage expands `component` actions of a text page, recording all the components (including the ones
+// used by other components) into used
+func (g *pagesGenerator) expandTextPage(input string, in io.Reader, out io.Writer, used *types.Set[string]) error {
+	return text_parser.Parse(text_parser.Params{
+		FileName: input,
+		Input:    in,
+		Output:   out,
+		GetComponent: func(name string, attrs map[string]any) (text_parser.Component, error) {
+			used.Add(template_parser.LookupName(name))
+			return g.textComponents.GetComponentUses(name, attrs, used.Add)
+		},
+		Delims: toDelims(g.pages.Delims),
+	})
+}
+
+// layoutDependency is the name, under which a page's use of layout name is recorded
+func layoutDependency(name string) string {
+	return "layout:" + strings.ToLower(name)
+}
+
+// getLayout finds layout file for name, in the layouts directory
+func (g *pagesGenerator) getLayout(name string) (string, io.Reader, error) {
+	if g.cfg.Layouts == nil {
+		return "", nil, fmt.Errorf("unknown layout (%s), no layouts configured", name)
+	}
+
+	file := filepath.Join(g.cfg.Layouts.Dir, filepath.FromSlash(name))
+	if rel, err := filepath.Rel(g.cfg.Layouts.Dir, file); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", nil, fmt.Errorf("invalid layout (%s), it is outside layouts dir", name)
+	}
+
+	matches, err := filepath.Glob(file + ".*")
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, file := range matches {
+		if !matchesAny(g.cfg.Layouts.Patterns, file) {
+			continue
+		}
+
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return "", nil, err
+		}
+
+		return file, bytes.NewReader(b), nil
+	}
+
+	return "", nil, fmt.Errorf("unknown layout (%s)", name)
+}
+
+// removePage removes generated output of a page, that no longer exists
+func (g *pagesGenerator) removePage(entry string) error {
+	delete(g.usedComponents, entry)
+	return g.output.RemovePage(entry)
+}
+
+// pagesUsing returns all the pages, that use any of the components
+func (g *pagesGenerator) pagesUsing(components []string) []string {
+	var pages []string
+	for entry, used := range g.usedComponents {
+		for _, c := range components {
+			if used.Has(c) {
+				pages = append(pages, entry)
+				break
+			}
+		}
+	}
+
+	return pages
+}
+
+func (g *pagesGenerator) generatePages() error {
+	outputDir := g.pages.Output.Dir
+
+	if err := g.output.Reset(); err != nil {
+		return err
+	}
+
+	listings, err := fn.RecursiveLs(g.pages.Input, g.pages.Patterns)
+	if err != nil {
+		return err
+	}
+
+	if len(listings) == 0 {
+		return fmt.Errorf("pages (%s): pattern matches no files: %#q", g.pages.Input, g.pages.Patterns)
+	}
+
+	if g.goParser != nil {
+		if err := os.MkdirAll(outputDir, 0o766); err != nil {
+			return err
+		}
+
+		if err := g.goParser.PrintPkgInitFile(template_parser.PrintPkgInitFileArgs{
+			Dir:                     outputDir,
+			Package:                 g.pages.Output.Package,
+			GeneratingForComponents: false,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// INFO: all pages are generated, even if some of them fail, so that all the errors are reported together
+	var errs []error
+	for _, entry := range listings {
+		if err := g.generatePage(entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// pageSets are the generators, of all the page sets
+type pageSets []*pagesGenerator
+
+// newPageSets creates a generator for every page set, output returns where a page set is written to, and it could
+// be nil, to write every page set into its output dir
+func newPageSets(cfg *Config, output func(pages *Pages) pagesOutput) (pageSets, error) {
+	var result pageSets
+	for i := range cfg.Pages {
+		var out pagesOutput
+		if output != nil {
+			out = output(&cfg.Pages[i])
+		}
+
+		g, err := newPagesGenerator(cfg, &cfg.Pages[i], out)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, g)
+	}
+
+	return result, nil
+}
+
+func (ps pageSets) parseComponents() error {
+	var errs []error
+	for _, g := range ps {
+		if err := g.parseComponents(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// generatePages generates all the page sets, even if some of them fail
+func (ps pageSets) generatePages() error {
+	var errs []error
+	for _, g := range ps {
+		if err := g.generatePages(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -1,20 +1,17 @@
 package main
 
 import (
-	_ "embed"
-	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
+	"strings"
 
 	flag "github.com/spf13/pflag"
 
-	"github.com/nxtcoder17/htmlc/cmd/templates"
 	"github.com/nxtcoder17/htmlc/examples"
-	template_parser "github.com/nxtcoder17/htmlc/pkg/parser/template"
 
 	"github.com/nxtcoder17/fastlog"
 )
@@ -25,16 +22,24 @@ func isAbs(p string) bool {
 }
 
 func sanitizeConfig(cfg *Config) {
-	if !isAbs(cfg.Pages.Input) {
-		cfg.Pages.Input = filepath.Join(cfg.WorkingDir, cfg.Pages.Input)
-	}
+	for i := range cfg.Pages {
+		pages := &cfg.Pages[i]
+		if !isAbs(pages.Input) {
+			pages.Input = filepath.Join(cfg.WorkingDir, pages.Input)
+		}
 
-	if !isAbs(cfg.Pages.Output.Dir) {
-		cfg.Pages.Output.Dir = filepath.Join(cfg.WorkingDir, cfg.Pages.Output.Dir)
-	}
+		if !isAbs(pages.Output.Dir) {
+			pages.Output.Dir = filepath.Join(cfg.WorkingDir, pages.Output.Dir)
+		}
 
-	if !isAbs(cfg.generatorDir) {
-		cfg.generatorDir = filepath.Join(cfg.WorkingDir, cfg.generatorDir)
+		if len(pages.Patterns) == 0 {
+			pages.Patterns = pagesPatterns
+			if isText(pages.Type) {
+				pages.Patterns = textPagesPatterns
+			}
+		}
+
+		pages.Prefix = path.Clean("/" + pages.Prefix)
 	}
 
 	for i := range cfg.Components {
@@ -42,110 +47,51 @@ func sanitizeConfig(cfg *Config) {
 			cfg.Components[i].Dir = filepath.Join(cfg.WorkingDir, cfg.Components[i].Dir)
 		}
 	}
-}
-
-//go:embed templates/pages-generator.gotmpl
-var generatorGoCode string
-
-func generator(cfg *Config) error {
-	sanitizeConfig(cfg)
-
-	p, err := template_parser.NewParser(template_parser.Html)
-	if err != nil {
-		return err
-	}
 
-	slog.Debug("parsing components directory")
-
-	// First Sweep
-	for _, tc := range cfg.Components {
-		if err := p.ParseDir(tc.Dir, cfg.generatorDir, "main", template_parser.ParseOptions{
-			GlobPatterns:            tc.Patterns,
-			GeneratingForComponents: true,
-		}); err != nil {
-			return err
+	if cfg.Layouts != nil {
+		if !isAbs(cfg.Layouts.Dir) {
+			cfg.Layouts.Dir = filepath.Join(cfg.WorkingDir, cfg.Layouts.Dir)
 		}
-	}
 
-	slog.Info("generating pages")
-	if err := generatePagesFile(cfg); err != nil {
-		return err
-	}
-
-	return executor(cfg)
-}
-
-func executeCmd(cfg *Config, command string, args ...string) error {
-	c := exec.Command(command, args...)
-	c.Dir = cfg.generatorDir
-	b, err := c.CombinedOutput()
-	if err != nil {
-		if exerr, ok := err.(*exec.ExitError); ok && exerr.ExitCode() != 0 {
-			fmt.Printf("%s\n", b)
+		if len(cfg.Layouts.Patterns) == 0 {
+			cfg.Layouts.Patterns = pagesPatterns
 		}
-		return err
 	}
 
-	if debug {
-		fmt.Printf("%s\n", b)
+	if cfg.Schema != nil && !isAbs(cfg.Schema.Dir) {
+		cfg.Schema.Dir = filepath.Join(cfg.WorkingDir, cfg.Schema.Dir)
 	}
 
-	return nil
-}
-
-func executor(cfg *Config) error {
-	if _, err := os.Stat(filepath.Join(cfg.generatorDir, "go.mod")); err != nil && errors.Is(err, os.ErrNotExist) {
-		if err := executeCmd(cfg, "go", "mod", "init", "github.com/nxtcoder17/htmlc.cli"); err != nil {
-			return err
+	if cfg.Export != nil {
+		if !isAbs(cfg.Export.Dir) {
+			cfg.Export.Dir = filepath.Join(cfg.WorkingDir, cfg.Export.Dir)
 		}
-	}
 
-	if err := executeCmd(cfg, "go", "get", "github.com/nxtcoder17/htmlc@"+Version); err != nil {
-		return err
-	}
-
-	if err := executeCmd(cfg, "go", "mod", "tidy"); err != nil {
-		return err
-	}
-
-	if err := executeCmd(cfg, "go", "run", "./"); err != nil {
-		return err
-	}
-
-	if !debug {
-		slog.Debug("HERE, deleting generator directory", "debug", debug)
-		if err := os.RemoveAll(cfg.generatorDir); err != nil {
-			return err
+		if cfg.Export.Assets != "" && !isAbs(cfg.Export.Assets) {
+			cfg.Export.Assets = filepath.Join(cfg.WorkingDir, cfg.Export.Assets)
 		}
 	}
-
-	return nil
 }
 
-func generatePagesFile(cfg *Config) error {
-	rel, err := filepath.Rel(cfg.generatorDir, cfg.Pages.Input)
+func generator(cfg *Config) error {
+	sanitizeConfig(cfg)
+
+	ps, err := newPageSets(cfg, nil)
 	if err != nil {
 		return err
 	}
 
-	b2, err := templates.ParseBytes([]byte(generatorGoCode), map[string]any{
-		"package":         "main",
-		"input_pages_dir": rel,
-
-		"output_pages_dir":     cfg.Pages.Output.Dir,
-		"output_pages_package": cfg.Pages.Output.Package,
-
-		"gen_go_code": cfg.Pages.Output.Go,
-	})
-	if err != nil {
+	slog.Debug("parsing components directory")
+	if err := ps.parseComponents(); err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(filepath.Join(cfg.generatorDir, "generator.go"), b2, 0o644); err != nil {
+	if err := writeSchemas(cfg); err != nil {
 		return err
 	}
 
-	return nil
+	slog.Info("generating pages")
+	return ps.generatePages()
 }
 
 var (
@@ -154,7 +100,15 @@ var (
 )
 
 func showHelp() {
-	fmt.Println("must specify, one command [init|generate]")
+	fmt.Println("must specify, one command [init|generate|watch|serve|export]")
+}
+
+// logErrors logs msg, followed by every line of err
+func logErrors(msg string, err error) {
+	slog.Error(msg)
+	for _, line := range strings.Split(err.Error(), "\n") {
+		fmt.Fprintf(os.Stderr, "  %s\n", line)
+	}
 }
 
 func pathExists(p string) bool {
@@ -163,8 +117,8 @@ func pathExists(p string) bool {
 }
 
 func subCommandInit() error {
-	if pathExists("htmlc.yml") || pathExists("components") || pathExists("pages") {
-		return fmt.Errorf("htmlc is already initialized as htmlc.yml | components | pages directory already exists")
+	if pathExists("htmlc.yml") || pathExists("components") || pathExists("pages") || pathExists("layouts") || pathExists("static") {
+		return fmt.Errorf("htmlc is already initialized as htmlc.yml | components | pages | layouts | static directory already exists")
 	}
 
 	return fs.WalkDir(examples.ExamplesFS, ".", func(path string, d fs.DirEntry, err error) error {
@@ -189,9 +143,16 @@ func subCommandInit() error {
 func main() {
 	f := flag.String("config", "htmlc.yml", "--config")
 	flag.BoolVar(&debug, "debug", false, "--debug")
+	addr := flag.String("addr", ":3000", "--addr <host:port>, address to serve pages on, with `serve` command")
 	flag.Parse()
 
-	logger := fastlog.New(fastlog.WithoutTimestamp(), fastlog.ShowDebugLogs(debug))
+	logOpts := []fastlog.OptionFn{fastlog.WithoutTimestamp()}
+	if debug {
+		// INFO: fastlog.ShowDebugLogs enables debug logs, irrespective of the value passed to it
+		logOpts = append(logOpts, fastlog.ShowDebugLogs(true))
+	}
+
+	logger := fastlog.New(logOpts...)
 	slog.SetDefault(logger.Slog())
 
 	if len(flag.CommandLine.Args()) == 0 {
@@ -217,7 +178,43 @@ func main() {
 			}
 
 			if err := generator(c); err != nil {
-				logger.Error("failed to generate pages, got", "err", err)
+				logErrors("failed to generate pages, got", err)
+				os.Exit(1)
+			}
+		}
+	case "watch":
+		{
+			c, err := ConfigFromFile(*f)
+			if err != nil {
+				panic(err)
+			}
+
+			if err := watcher(c); err != nil {
+				logErrors("failed to watch pages, got", err)
+				os.Exit(1)
+			}
+		}
+	case "serve":
+		{
+			c, err := ConfigFromFile(*f)
+			if err != nil {
+				panic(err)
+			}
+
+			if err := server(c, *addr); err != nil {
+				logErrors("failed to serve pages, got", err)
+				os.Exit(1)
+			}
+		}
+	case "export":
+		{
+			c, err := ConfigFromFile(*f)
+			if err != nil {
+				panic(err)
+			}
+
+			if err := exporter(c); err != nil {
+				logErrors("failed to export pages, got", err)
 				os.Exit(1)
 			}
 		}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const reloadPath = "/__htmlc/reload"
+
+// reloadScript is injected into every served page, it reloads the page whenever server asks it to
+var reloadScript = []byte(fmt.Sprintf(`<script>new EventSource(%q).onmessage = () => window.location.reload()</script>`, reloadPath))
+
+// memoryOutput keeps generated pages in memory
+type memoryOutput struct {
+	sync.RWMutex
+	pages map[string][]byte
+}
+
+func newMemoryOutput() *memoryOutput {
+	return &memoryOutput{pages: make(map[string][]byte)}
+}
+
+func (m *memoryOutput) WritePage(entry string, b []byte) error {
+	m.Lock()
+	defer m.Unlock()
+	m.pages[path.Clean("/"+entry)] = b
+	return nil
+}
+
+func (m *memoryOutput) RemovePage(entry string) error {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.pages, path.Clean("/"+entry))
+	return nil
+}
+
+func (m *memoryOutput) Reset() error {
+	m.Lock()
+	defer m.Unlock()
+	m.pages = make(map[string][]byte)
+	return nil
+}
+
+// page finds a page (entry) for url path, i.e. `/users/register` could be served by
+// `/users/register`, `/users/register.html` or `/users/register/index.html`
+func (m *memoryOutput) page(urlPath string) (string, []byte, bool) {
+	m.RLock()
+	defer m.RUnlock()
+
+	p := path.Clean("/" + urlPath)
+	for _, candidate := range []string{p, p + ".html", path.Join(p, "index.html")} {
+		if b, ok := m.pages[candidate]; ok {
+			return strings.TrimPrefix(candidate, "/"), b, true
+		}
+	}
+
+	return "", nil, false
+}
+
+// injectReloadScript adds reload script at the end of <head>, or at the end of page, when it has no <head>
+func injectReloadScript(page []byte) []byte {
+	idx := bytes.Index(bytes.ToLower(page), []byte("</head>"))
+	if idx == -1 {
+		return append(append([]byte{}, page...), reloadScript...)
+	}
+
+	result := make([]byte, 0, len(page)+len(reloadScript))
+	result = append(result, page[:idx]...)
+	result = append(result, reloadScript...)
+	return append(result, page[idx:]...)
+}
+
+// reloader keeps track of open browser tabs, via server sent events
+type reloader struct {
+	sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+func (r *reloader) broadcast() {
+	r.Lock()
+	defer r.Unlock()
+	for c := range r.clients {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (r *reloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+
+	c := make(chan struct{}, 1)
+
+	r.Lock()
+	r.clients[c] = struct{}{}
+	r.Unlock()
+
+	defer func() {
+		r.Lock()
+		delete(r.clients, c)
+		r.Unlock()
+	}()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-c:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// servedPages are the in memory pages, of a page set
+type servedPages struct {
+	pages  *Pages
+	output *memoryOutput
+}
+
+// page finds a page (entry) of a page set, for url path, under page set's prefix
+func (s servedPages) page(urlPath string) (string, []byte, bool) {
+	p := path.Clean("/" + urlPath)
+	if s.pages.Prefix != "/" {
+		if p != s.pages.Prefix && !strings.HasPrefix(p, s.pages.Prefix+"/") {
+			return "", nil, false
+		}
+		p = strings.TrimPrefix(p, s.pages.Prefix)
+	}
+
+	return s.output.page(p)
+}
+
+func server(cfg *Config, addr string) error {
+	sanitizeConfig(cfg)
+
+	var served []servedPages
+
+	ps, err := newPageSets(cfg, func(pages *Pages) pagesOutput {
+		output := newMemoryOutput()
+		served = append(served, servedPages{pages: pages, output: output})
+		return output
+	})
+	if err != nil {
+		return err
+	}
+
+	// INFO: page sets with longer prefixes are looked up first, i.e. /admin/users is served by /admin pages, even if / pages have it too
+	sort.SliceStable(served, func(i, j int) bool { return len(served[i].pages.Prefix) > len(served[j].pages.Prefix) })
+
+	if err := ps.parseComponents(); err != nil {
+		return err
+	}
+
+	if err := writeSchemas(cfg); err != nil {
+		return err
+	}
+
+	slog.Info("generating pages")
+	if err := ps.generatePages(); err != nil {
+		logErrors("failed to generate pages, got", err)
+	}
+
+	r := &reloader{clients: make(map[chan struct{}]struct{})}
+
+	mux := http.NewServeMux()
+	mux.Handle(reloadPath, r)
+	assets := assetsHandler(cfg)
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		for _, s := range served {
+			if entry, b, ok := s.page(req.URL.Path); ok {
+				servePage(w, s.pages, entry, b)
+				return
+			}
+		}
+
+		// INFO: pages take precedence over assets, as they do when both are exported
+		assets.ServeHTTP(w, req)
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ps.watch(cfg, r.broadcast)
+	}()
+
+	go func() {
+		slog.Info("serving pages", "addr", addr)
+		errCh <- http.ListenAndServe(addr, mux)
+	}()
+
+	return <-errCh
+}
+
+// assetsHandler serves `export.assets` directory, at the same url path as it is exported at, i.e. ./static at /static
+func assetsHandler(cfg *Config) http.Handler {
+	if cfg.Export == nil || cfg.Export.Assets == "" {
+		return http.NotFoundHandler()
+	}
+
+	prefix := "/" + filepath.Base(cfg.Export.Assets)
+	files := http.StripPrefix(prefix, http.FileServer(http.Dir(cfg.Export.Assets)))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.URL.Path, prefix+"/") {
+			http.NotFound(w, req)
+			return
+		}
+		files.ServeHTTP(w, req)
+	})
+}
+
+// servePage writes generated page (entry) b of page set pages, executed with its data file, like it is exported
+func servePage(w http.ResponseWriter, pages *Pages, entry string, b []byte) {
+	// INFO: page is executed on every request, so that changes of its data file are served on reload
+	out, err := renderPage(pages.Input, entry, b, toDelims(pages.Delims), isText(pages.Type))
+	if err != nil {
+		err = prefixErrors(filepath.Join(pages.Input, entry), err)
+		logErrors("failed to render page, got", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// INFO: text pages are served as is, as a script could not reload them
+	if isText(pages.Type) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(out)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(injectReloadScript(out))
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/nxtcoder17/htmlc/pkg/types"
+)
+
+// debounceInterval is the time, watcher waits for more events, before regenerating
+// as editors tend to emit multiple events for a single save
+const debounceInterval = 100 * time.Millisecond
+
+func isUnder(dir string, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func matchesAny(patterns []string, p string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, filepath.Base(p)); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// watchRecursively watches dir, and all its subdirectories, onFile is called for every file found in them
+func watchRecursively(w *fsnotify.Watcher, dir string, onFile func(p string)) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return w.Add(path)
+		}
+
+		if onFile != nil {
+			onFile(path)
+		}
+
+		return nil
+	})
+}
+
+type watchChanges struct {
+	components *types.Set[string]
+	layouts    *types.Set[string]
+
+	// pages are the changed page files, of any page set
+	pages *types.Set[string]
+}
+
+func newWatchChanges() watchChanges {
+	return watchChanges{
+		components: types.NewSet[string](),
+		layouts:    types.NewSet[string](),
+		pages:      types.NewSet[string](),
+	}
+}
+
+// record classifies a changed path, as either a component file, a layout or a page
+func (wc watchChanges) record(cfg *Config, p string) {
+	for _, pages := range cfg.Pages {
+		if isUnder(pages.Output.Dir, p) {
+			return
+		}
+	}
+
+	for _, tc := range cfg.Components {
+		if isUnder(tc.Dir, p) && matchesAny(tc.Patterns, p) {
+			wc.components.Add(p)
+			return
+		}
+	}
+
+	if cfg.Layouts != nil && isUnder(cfg.Layouts.Dir, p) && matchesAny(cfg.Layouts.Patterns, p) {
+		rel, _ := filepath.Rel(cfg.Layouts.Dir, p)
+		wc.layouts.Add(filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel))))
+		return
+	}
+
+	for _, pages := range cfg.Pages {
+		if isUnder(pages.Input, p) && matchesAny(pages.Patterns, p) {
+			wc.pages.Add(p)
+			return
+		}
+	}
+}
+
+func (wc watchChanges) isEmpty() bool {
+	return wc.components.Len() == 0 && wc.layouts.Len() == 0 && wc.pages.Len() == 0
+}
+
+// regenerate rebuilds only what changed, in every page set
+func (ps pageSets) regenerate(cfg *Config, changes watchChanges) {
+	for _, g := range ps {
+		g.regenerate(changes)
+	}
+
+	if changes.components.Len() > 0 {
+		if err := writeSchemas(cfg); err != nil {
+			logErrors("failed to write component schemas, got", err)
+		}
+	}
+}
+
+// regenerate rebuilds only what changed, in page set:
+//   - a changed page is rebuilt
+//   - a changed component file, rebuilds every page that uses any component defined in it (directly, or via other components)
+//   - a changed layout, rebuilds every page that uses it (directly, or via other layouts)
+func (g *pagesGenerator) regenerate(changes watchChanges) {
+	pages := types.NewSet[string]()
+	for _, p := range changes.pages.Items() {
+		if isUnder(g.pages.Input, p) && matchesAny(g.pages.Patterns, p) {
+			pages.Add(strings.TrimPrefix(p, g.pages.Input))
+		}
+	}
+
+	if changes.components.Len() > 0 {
+		var names []string
+		for _, file := range changes.components.Items() {
+			names = append(names, g.fileComponents(file)...)
+		}
+
+		if err := g.parseComponents(); err != nil {
+			logErrors("failed to parse components, got", err)
+			names = nil
+		}
+
+		for _, file := range changes.components.Items() {
+			names = append(names, g.fileComponents(file)...)
+		}
+
+		for _, entry := range g.pagesUsing(names) {
+			pages.Add(entry)
+		}
+	}
+
+	if changes.layouts.Len() > 0 {
+		var names []string
+		for _, name := range changes.layouts.Items() {
+			names = append(names, layoutDependency(name))
+		}
+
+		for _, entry := range g.pagesUsing(names) {
+			pages.Add(entry)
+		}
+	}
+
+	for _, entry := range pages.Items() {
+		if _, err := os.Stat(filepath.Join(g.pages.Input, entry)); err != nil && os.IsNotExist(err) {
+			slog.Info("removing page", "page", entry)
+			if err := g.removePage(entry); err != nil {
+				slog.Error("failed to remove page, got", "page", entry, "err", err)
+			}
+			continue
+		}
+
+		slog.Info("regenerating page", "page", entry)
+		if err := g.generatePage(entry); err != nil {
+			logErrors(fmt.Sprintf("failed to generate page (%s), got", entry), err)
+		}
+	}
+}
+
+func watcher(cfg *Config) error {
+	sanitizeConfig(cfg)
+
+	ps, err := newPageSets(cfg, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := ps.parseComponents(); err != nil {
+		return err
+	}
+
+	if err := writeSchemas(cfg); err != nil {
+		return err
+	}
+
+	slog.Info("generating pages")
+	if err := ps.generatePages(); err != nil {
+		logErrors("failed to generate pages, got", err)
+	}
+
+	return ps.watch(cfg, nil)
+}
+
+// watch watches components, layouts and pages directories (of all page sets), and regenerates on changes, until
+// the watcher stops. onRegenerated (if not nil) is called after every regeneration
+func (ps pageSets) watch(cfg *Config, onRegenerated func()) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	var dirs []string
+	for _, pages := range cfg.Pages {
+		dirs = append(dirs, pages.Input)
+	}
+	for _, tc := range cfg.Components {
+		dirs = append(dirs, tc.Dir)
+	}
+
+	if cfg.Layouts != nil {
+		dirs = append(dirs, cfg.Layouts.Dir)
+	}
+
+	for _, dir := range dirs {
+		if err := watchRecursively(w, dir, nil); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("watching for changes", "dirs", dirs)
+
+	changes := newWatchChanges()
+	debounce := time.NewTimer(debounceInterval)
+	debounce.Stop()
+
+	for {
+		select {
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+
+			if ev.Has(fsnotify.Chmod) {
+				continue
+			}
+
+			if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+				if ev.Has(fsnotify.Create) {
+					// INFO: files could have been created, before the directory was being watched
+					if err := watchRecursively(w, ev.Name, func(p string) { changes.record(cfg, p) }); err != nil {
+						slog.Error("failed to watch directory, got", "dir", ev.Name, "err", err)
+					}
+					debounce.Reset(debounceInterval)
+				}
+				continue
+			}
+
+			changes.record(cfg, ev.Name)
+			debounce.Reset(debounceInterval)
+
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Error("watcher, got", "err", err)
+
+		case <-debounce.C:
+			if changes.isEmpty() {
+				continue
+			}
+
+			ps.regenerate(cfg, changes)
+			changes = newWatchChanges()
+
+			if onRegenerated != nil {
+				onRegenerated()
+			}
+		}
+	}
+}
@@ -4,5 +4,5 @@ import (
 	"embed"
 )
 
-//go:embed htmlc.yml components pages
+//go:embed htmlc.yml components pages layouts static
 var ExamplesFS embed.FS
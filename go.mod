@@ -3,6 +3,7 @@ module github.com/nxtcoder17/htmlc
 go 1.23.0
 
 require (
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/go-playground/validator/v10 v10.24.0
 	github.com/nxtcoder17/fastlog v0.0.0-20250814133635-62402a0f0354
 	github.com/spf13/pflag v1.0.7
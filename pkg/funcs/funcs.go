@@ -0,0 +1,130 @@
+package funcs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// FuncMap returns the functions, available in every template, their arguments follow sprig's order,
+// so that the value being worked on comes last, i.e. {{ .name | default "guest" | title }}
+func FuncMap() map[string]any {
+	return map[string]any{
+		// strings
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"title": func(s string) string {
+			res := []rune(s)
+			for i := range res {
+				if i == 0 || unicode.IsSpace(res[i-1]) {
+					res[i] = unicode.ToUpper(res[i])
+				}
+			}
+			return string(res)
+		},
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+		"join": func(sep string, items any) (string, error) {
+			v := reflect.ValueOf(items)
+			if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+				return "", fmt.Errorf("join: expected a list, got %T", items)
+			}
+
+			res := make([]string, 0, v.Len())
+			for i := 0; i < v.Len(); i++ {
+				res = append(res, fmt.Sprint(v.Index(i).Interface()))
+			}
+			return strings.Join(res, sep), nil
+		},
+		"toString": func(v any) string { return fmt.Sprint(v) },
+
+		// defaults
+		"default": func(def any, v any) any {
+			if isEmptyValue(v) {
+				return def
+			}
+			return v
+		},
+		"empty": isEmptyValue,
+
+		// collections
+		"list": func(items ...any) []any { return items },
+		"dict": func(pairs ...any) (map[string]any, error) {
+			if len(pairs)%2 != 0 {
+				return nil, errors.New("dict: expected key and value pairs")
+			}
+
+			res := make(map[string]any, len(pairs)/2)
+			for i := 0; i < len(pairs); i += 2 {
+				k, ok := pairs[i].(string)
+				if !ok {
+					return nil, fmt.Errorf("dict: keys must be strings, got %T", pairs[i])
+				}
+				res[k] = pairs[i+1]
+			}
+			return res, nil
+		},
+
+		// math, on integers
+		"add": func(a, b any) (int, error) { return intOp(a, b, func(x, y int) int { return x + y }) },
+		"sub": func(a, b any) (int, error) { return intOp(a, b, func(x, y int) int { return x - y }) },
+		"mul": func(a, b any) (int, error) { return intOp(a, b, func(x, y int) int { return x * y }) },
+		"div": func(a, b any) (int, error) {
+			if y, err := intValue(b); err == nil && y == 0 {
+				return 0, errors.New("div: division by zero")
+			}
+			return intOp(a, b, func(x, y int) int { return x / y })
+		},
+		"mod": func(a, b any) (int, error) {
+			if y, err := intValue(b); err == nil && y == 0 {
+				return 0, errors.New("mod: division by zero")
+			}
+			return intOp(a, b, func(x, y int) int { return x % y })
+		},
+	}
+}
+
+// isEmptyValue reports whether v is the zero value of its type, or an empty collection
+func isEmptyValue(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String, reflect.Chan:
+		return rv.Len() == 0
+	}
+	return rv.IsZero()
+}
+
+func intValue(v any) (int, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(rv.Uint()), nil
+	}
+	return 0, fmt.Errorf("expected an integer, got %T", v)
+}
+
+func intOp(a, b any, op func(x, y int) int) (int, error) {
+	x, err := intValue(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := intValue(b)
+	if err != nil {
+		return 0, err
+	}
+	return op(x, y), nil
+}
@@ -0,0 +1,84 @@
+package funcs
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestFuncMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    string
+		data    map[string]any
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "1. string functions",
+			tmpl: `{{ .name | title }} {{ upper "a" }} {{ "x-y" | replace "-" "_" }} {{ hasPrefix "he" "hello" }}`,
+			data: map[string]any{"name": "john doe"},
+			want: "John Doe A x_y true",
+		},
+		{
+			name: "2. default, on missing and empty values",
+			tmpl: `{{ .missing | default "guest" }} {{ .empty | default "none" }} {{ .name | default "guest" }}`,
+			data: map[string]any{"empty": "", "name": "john"},
+			want: "guest none john",
+		},
+		{
+			name: "3. join, and split",
+			tmpl: `{{ .tags | join ", " }} {{ range split "," "a,b" }}[{{ . }}]{{ end }}`,
+			data: map[string]any{"tags": []int{1, 2}},
+			want: "1, 2 [a][b]",
+		},
+		{
+			name: "4. dict, and list",
+			tmpl: `{{ $d := dict "a" 1 "b" (list 2 3) }}{{ $d.a }} {{ index $d.b 1 }}`,
+			want: "1 3",
+		},
+		{
+			name:    "5. dict, with a missing value",
+			tmpl:    `{{ dict "a" }}`,
+			wantErr: true,
+		},
+		{
+			name: "6. math, with mixed integer types",
+			tmpl: `{{ add .count 1 }} {{ sub 5 2 }} {{ mul 2 3 }} {{ div 7 2 }} {{ mod 7 2 }}`,
+			data: map[string]any{"count": int64(41)},
+			want: "42 3 6 3 1",
+		},
+		{
+			name:    "7. division by zero",
+			tmpl:    `{{ div 1 0 }}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := template.New("t").Funcs(FuncMap()).Parse(tt.tmpl)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b := new(strings.Builder)
+			err = tmpl.Execute(b, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && b.String() != tt.want {
+				t.Errorf("Execute() = %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSource(t *testing.T) {
+	src := Source("pages")
+	if !strings.HasPrefix(src, "package pages\n") {
+		t.Errorf("Source() does not start with package clause, for pages package")
+	}
+}
@@ -0,0 +1,16 @@
+// Package funcs has the functions, that are available in every htmlc template
+package funcs
+
+import (
+	_ "embed"
+	"strings"
+)
+
+//go:embed funcs.go
+var source string
+
+// Source returns go source of [FuncMap], for package pkg, generated packages have it as their own code,
+// so that they do not depend on htmlc
+func Source(pkg string) string {
+	return strings.Replace(source, "package funcs\n", "package "+pkg+"\n", 1)
+}
@@ -2,6 +2,7 @@ package html
 
 import (
 	"fmt"
+	"log/slog"
 	"strings"
 
 	"golang.org/x/net/html"
@@ -32,5 +33,5 @@ func nodePrinter(n *html.Node, withChildren bool) (string, []string) {
 
 func logNode(msg string, n *html.Node) {
 	s, c := nodePrinter(n, true)
-	logger.Info(msg, "element", s, "children", c)
+	slog.Info(msg, "element", s, "children", c)
 }
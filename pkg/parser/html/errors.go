@@ -0,0 +1,218 @@
+package html
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Error is an error, encountered while expanding a component tag
+type Error struct {
+	// File is the page file, where the component tag is used
+	File string
+
+	// Line, and Col point to the component tag in File, they are 0 when unknown
+	Line int
+	Col  int
+
+	// Tag is the component tag, as written in the source
+	Tag string
+
+	// Source describes where the component is defined, when known
+	Source string
+
+	Err error
+}
+
+func (e *Error) Error() string {
+	sb := new(strings.Builder)
+	if e.Line > 0 {
+		fmt.Fprintf(sb, "%s:%d:%d: ", e.File, e.Line, e.Col)
+	}
+
+	fmt.Fprintf(sb, "<%s>", e.Tag)
+	if e.Source != "" {
+		fmt.Fprintf(sb, " (%s)", e.Source)
+	}
+
+	fmt.Fprintf(sb, ": %s", e.Err)
+	return sb.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// PropError is returned by [Params.GetComponent], when a component is given an invalid attribute
+type PropError struct {
+	// Component is the name of the component
+	Component string
+
+	// Source describes where the component is defined, when known
+	Source string
+
+	// Field is the attribute name
+	Field string
+
+	// Rule is the validation rule, that the attribute failed on, i.e. required
+	Rule string
+
+	Err error
+}
+
+func (e *PropError) Error() string {
+	sb := new(strings.Builder)
+	sb.WriteString(e.Component)
+	if e.Source != "" {
+		fmt.Fprintf(sb, " (%s)", e.Source)
+	}
+
+	fmt.Fprintf(sb, ": attribute (%s) failed on (%s)", e.Field, e.Rule)
+	if e.Err != nil {
+		fmt.Fprintf(sb, ": %s", e.Err)
+	}
+
+	return sb.String()
+}
+
+func (e *PropError) Unwrap() error {
+	return e.Err
+}
+
+// ComponentSource is optionally implemented by a [Component], to describe where it is defined, in error messages
+type ComponentSource interface {
+	Source() string
+}
+
+// FlattenErrors unwraps errors created with [errors.Join], nested ones too
+func FlattenErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var result []error
+		for _, e := range joined.Unwrap() {
+			result = append(result, FlattenErrors(e)...)
+		}
+		return result
+	}
+
+	return []error{err}
+}
+
+const (
+	// posAttr, and tagAttr are added to component tags in a page, to track their source position
+	// and tag name (as written, html parser lowercases it), they are removed before rendering
+	posAttr = "data-htmlc-pos"
+	tagAttr = "data-htmlc-tag"
+)
+
+// markComponentPositions annotates every component tag in b, with its line, and column
+func markComponentPositions(b []byte) []byte {
+	return markComponents(b, true)
+}
+
+// markComponentTags annotates every component tag in b, with its tag name only, as positions in a rendered
+// component do not point to any file
+func markComponentTags(b []byte) []byte {
+	return markComponents(b, false)
+}
+
+// markComponents walks the tokens of b, so that text looking like a tag, inside comments, <script> or <style>, is kept as is
+func markComponents(b []byte, positions bool) []byte {
+	out := new(bytes.Buffer)
+	z := html.NewTokenizer(bytes.NewReader(b))
+
+	// INFO: written is the offset, till where b has been written to out, as raw bytes of all the tokens make up b
+	line, col, written := 1, 1, 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+
+		raw := z.Raw()
+		written += len(raw)
+
+		if tt == html.StartTagToken || tt == html.SelfClosingTagToken {
+			name := rawTagName(raw)
+			out.Write(raw[:1+len(name)])
+
+			// INFO: html elements written in lowercase are not marked, any other tag could be a component, or a <Slot />
+			if lname := strings.ToLower(name); name != lname || !HTMLTags.Has(lname) {
+				if positions {
+					fmt.Fprintf(out, ` %s="%d:%d"`, posAttr, line, col)
+				}
+				fmt.Fprintf(out, ` %s="%s"`, tagAttr, name)
+			}
+
+			out.Write(raw[1+len(name):])
+		} else {
+			out.Write(raw)
+		}
+
+		for _, c := range raw {
+			if c == '\n' {
+				line, col = line+1, 1
+				continue
+			}
+			col++
+		}
+	}
+	out.Write(b[min(written, len(b)):])
+
+	return out.Bytes()
+}
+
+// rawTagName returns tag name of a start tag, as written, i.e. Card for <Card class="x">
+func rawTagName(raw []byte) string {
+	end := bytes.IndexAny(raw[1:], " \t\n\r\f/>")
+	if end == -1 {
+		return string(raw[1:])
+	}
+	return string(raw[1 : 1+end])
+}
+
+// componentPosition returns line, column and tag name of a component node, as marked by [markComponentPositions]
+func componentPosition(n *html.Node) (line int, col int, tag string) {
+	tag = n.Data
+	if v, ok := getAttr(n, tagAttr); ok {
+		tag = v
+	}
+
+	if v, ok := getAttr(n, posAttr); ok {
+		if l, c, found := strings.Cut(v, ":"); found {
+			line, _ = strconv.Atoi(l)
+			col, _ = strconv.Atoi(c)
+		}
+	}
+
+	return line, col, tag
+}
+
+// removePositionAttrs removes attributes added by [markComponentPositions], from n and its children
+func removePositionAttrs(n *html.Node) {
+	if n.Type == html.ElementNode {
+		n.Attr = removeAttr(removeAttr(n.Attr, posAttr), tagAttr)
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		removePositionAttrs(c)
+	}
+}
+
+// componentError wraps err (which could be joined errors) into errors, pointing at component node n
+func componentError(file string, n *html.Node, source string, err error) []error {
+	line, col, tag := componentPosition(n)
+
+	var result []error
+	for _, e := range FlattenErrors(err) {
+		result = append(result, &Error{File: file, Line: line, Col: col, Tag: tag, Source: source, Err: e})
+	}
+
+	return result
+}
@@ -15,7 +15,7 @@ type example struct {
 }
 
 // Render implements Component.
-func (e *example) Render(t *template.Template, w io.Writer) error {
+func (e *example) Render(w io.Writer) error {
 	t, err := template.New("sample").Parse(`
 {{ define "example" }}
 <div class="example {{.class}}">
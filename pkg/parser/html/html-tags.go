@@ -1,15 +1,135 @@
 package html
 
-import "github.com/nxtcoder17/htmlc/pkg/types"
-
-// @generated with CHATGPT
-var HTMLTags = types.NewSet("a", "abbr", "address", "area", "article", "aside", "audio", "b", "base", "bdi", "bdo", "blockquote",
-	"body", "br", "button", "canvas", "caption", "cite", "code", "col", "colgroup", "data", "datalist",
-	"dd", "del", "details", "dfn", "dialog", "div", "dl", "dt", "em", "embed", "fieldset", "figcaption",
-	"figure", "footer", "form", "h1", "h2", "h3", "h4", "h5", "h6", "head", "header", "hr", "html", "i",
-	"iframe", "img", "input", "ins", "kbd", "label", "legend", "li", "link", "main", "map", "mark", "meta",
-	"meter", "nav", "noscript", "object", "ol", "optgroup", "option", "output", "p", "picture", "pre",
-	"progress", "q", "rp", "rt", "ruby", "s", "samp", "script", "section", "select", "small", "source",
-	"span", "strong", "style", "sub", "summary", "sup", "svg", "table", "tbody", "td", "template", "textarea",
-	"tfoot", "th", "thead", "time", "title", "tr", "track", "u", "ul", "var", "video", "wbr",
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/nxtcoder17/htmlc/pkg/types"
+)
+
+// HTMLTags are the elements of the [HTML standard](https://html.spec.whatwg.org/multipage/indices.html#elements-3),
+// along with the obsolete ones, that browsers still parse
+var HTMLTags = types.NewSet(
+	"a", "abbr", "address", "area", "article", "aside", "audio",
+	"b", "base", "bdi", "bdo", "blockquote", "body", "br", "button",
+	"canvas", "caption", "cite", "code", "col", "colgroup",
+	"data", "datalist", "dd", "del", "details", "dfn", "dialog", "div", "dl", "dt",
+	"em", "embed",
+	"fieldset", "figcaption", "figure", "footer", "form",
+	"h1", "h2", "h3", "h4", "h5", "h6", "head", "header", "hgroup", "hr", "html",
+	"i", "iframe", "img", "input", "ins",
+	"kbd",
+	"label", "legend", "li", "link",
+	"main", "map", "mark", "menu", "meta", "meter",
+	"nav", "noscript",
+	"object", "ol", "optgroup", "option", "output",
+	"p", "picture", "pre", "progress",
+	"q",
+	"rp", "rt", "ruby",
+	"s", "samp", "script", "search", "section", "select", "slot", "small", "source", "span", "strong", "style", "sub", "summary", "sup",
+	"table", "tbody", "td", "template", "textarea", "tfoot", "th", "thead", "time", "title", "tr", "track",
+	"u", "ul",
+	"var", "video",
+	"wbr",
+
+	// obsolete
+	"acronym", "applet", "basefont", "bgsound", "big", "blink", "center", "dir", "font", "frame", "frameset", "image",
+	"isindex", "keygen", "listing", "marquee", "menuitem", "multicol", "nextid", "nobr", "noembed", "noframes", "param",
+	"plaintext", "rb", "rtc", "spacer", "strike", "tt", "xmp",
+
+	// roots of svg, and mathml content
+	"svg", "math",
+)
+
+// SVGTags are the elements of [SVG 2](https://www.w3.org/TR/SVG2/eltindex.html), along with the ones removed from SVG 1.1,
+// lowercased, as html parser does
+var SVGTags = types.NewSet(
+	"a", "animate", "animatemotion", "animatetransform",
+	"circle", "clippath",
+	"defs", "desc", "discard",
+	"ellipse",
+	"feblend", "fecolormatrix", "fecomponenttransfer", "fecomposite", "feconvolvematrix", "fediffuselighting",
+	"fedisplacementmap", "fedistantlight", "fedropshadow", "feflood", "fefunca", "fefuncb", "fefuncg", "fefuncr",
+	"fegaussianblur", "feimage", "femerge", "femergenode", "femorphology", "feoffset", "fepointlight",
+	"fespecularlighting", "fespotlight", "fetile", "feturbulence", "filter", "foreignobject",
+	"g",
+	"image",
+	"line", "lineargradient",
+	"marker", "mask", "metadata", "mpath",
+	"path", "pattern", "polygon", "polyline",
+	"radialgradient", "rect",
+	"script", "set", "stop", "style", "svg", "switch", "symbol",
+	"text", "textpath", "title", "tspan",
+	"use",
+	"view",
+
+	// SVG 1.1
+	"altglyph", "altglyphdef", "altglyphitem", "animatecolor", "cursor", "font", "font-face", "font-face-format",
+	"font-face-name", "font-face-src", "font-face-uri", "glyph", "glyphref", "hkern", "missing-glyph", "tref", "vkern",
+)
+
+// MathMLTags are the elements of [MathML Core](https://www.w3.org/TR/mathml-core/#mathml-elements-and-attributes),
+// along with the presentation elements of MathML 3
+var MathMLTags = types.NewSet(
+	"annotation", "annotation-xml",
+	"math", "merror", "mfrac", "mi", "mmultiscripts", "mn", "mo", "mover", "mpadded", "mphantom", "mprescripts", "mroot",
+	"mrow", "ms", "mspace", "msqrt", "mstyle", "msub", "msubsup", "msup", "mtable", "mtd", "mtext", "mtr", "munder",
+	"munderover",
+	"semantics",
+
+	// MathML 3
+	"maction", "maligngroup", "malignmark", "menclose", "mfenced", "mglyph", "mlabeledtr", "mlongdiv", "mscarries",
+	"mscarry", "msgroup", "msline", "msrow", "mstack", "none",
 )
+
+// Elements decides which tags are components, and which ones are elements, kept as is. Tags of html elements, and
+// custom elements (i.e. <sl-button>, or <turbo-frame>) are kept as is, so are tags of svg and mathml elements, when
+// they are written in lowercase (i.e. <path /> rendered by a component, for an <svg>), any other tag is a component.
+// A registered component, written with an uppercase letter (i.e. <Menu>, or <Search>), is used instead of an element
+type Elements struct {
+	// Allow are patterns of tags (i.e. "x-*", or "mjml"), that are kept as is
+	Allow []string
+
+	// Deny are patterns of tags (i.e. "ui-*"), that are always components, even if they are custom elements
+	Deny []string
+
+	// Registered, when set, reports whether tag (as written, i.e. <Menu>) is a registered component
+	Registered func(tag string) bool
+}
+
+// IsComponent reports whether tag (as written, i.e. <Card>) is a component
+func (e Elements) IsComponent(tag string) bool {
+	name := strings.ToLower(tag)
+
+	switch {
+	case matchesAny(e.Deny, name):
+		return true
+	case matchesAny(e.Allow, name):
+		return false
+	case tag != name && e.Registered != nil && e.Registered(tag):
+		// INFO: lowercase tags are written as elements, i.e. <menu> is an html element, even with a Menu component
+		return true
+	case strings.Contains(name, ":"):
+		// INFO: a tag with a namespace (i.e. <ui:nav-bar>) is a component, as names of custom elements can not have a `:`
+		return true
+	case HTMLTags.Has(name):
+		return false
+	case (SVGTags.Has(name) || MathMLTags.Has(name)) && unicode.IsLower(rune(tag[0])):
+		return false
+	case strings.Contains(name, "-"):
+		// INFO: custom elements must have a hyphen in their name
+		return false
+	}
+
+	return true
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(strings.ToLower(pattern), name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package html
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/nxtcoder17/htmlc/pkg/types"
+	"golang.org/x/net/html"
+)
+
+// Layouts
+//
+// A page (or a layout itself, for nested layouts) declares its layout, with a root `<Layout name="base">` element.
+// Content of the `<Layout>` element fills the layout's slots, just like a component's children do.
+//
+// Page's `<head>` (and any `<title>`, `<meta>`, `<link>`, `<style>`, `<base>` directly under `<Layout>`) is merged
+// into the layout's `<head>`, where page's `<title>` replaces the layout's one.
+
+const layoutTag = "layout"
+
+var (
+	layoutPageRe = regexp.MustCompile(`(?is)^\s*(<!--.*?-->\s*)*<layout[\s>/]`)
+	documentRe   = regexp.MustCompile(`(?is)^\s*(<!--.*?-->\s*)*(<!doctype|<html[\s>])`)
+
+	headOpenRe  = regexp.MustCompile(`(?i)<head(\s[^>]*)?>`)
+	headCloseRe = regexp.MustCompile(`(?i)</head\s*>`)
+
+	headTags = types.NewSet("title", "meta", "link", "style", "base")
+)
+
+// usesLayout reports whether source declares a layout, i.e. its root element is <Layout>
+func usesLayout(b []byte) bool {
+	return layoutPageRe.Match(b)
+}
+
+// isDocument reports whether source is a complete html document, rather than a fragment, i.e. it starts with
+// <!doctype> or <html>, after any leading comments
+func isDocument(b []byte) bool {
+	return documentRe.Match(b)
+}
+
+// headAsSlot rewrites <head> as `<template slot="head">`, as html parser drops a <head> that is not
+// at the top of the document, and would spill its content into the body
+func headAsSlot(b []byte) []byte {
+	b = headOpenRe.ReplaceAll(b, []byte(`<template slot="head">`))
+	return headCloseRe.ReplaceAll(b, []byte(`</template>`))
+}
+
+// findLayoutNode returns the <Layout> node, if it is the only element at the root of n
+func findLayoutNode(n *html.Node) *html.Node {
+	if n.Type == html.ElementNode && n.Data == layoutTag {
+		return n
+	}
+
+	var layout *html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.CommentNode, c.Type == html.TextNode && strings.TrimSpace(c.Data) == "":
+			continue
+		case c.Type == html.ElementNode && c.Data == layoutTag && layout == nil:
+			layout = c
+		default:
+			return nil
+		}
+	}
+
+	return layout
+}
+
+// extractHead removes, and returns, the nodes of page that belong to the <head>
+func extractHead(page *html.Node) []*html.Node {
+	var result []*html.Node
+	for c := page.FirstChild; c != nil; {
+		next := c.NextSibling
+
+		if c.Type == html.ElementNode {
+			slot, isSlot := getAttr(c, slotTag)
+			switch {
+			case c.Data == "template" && isSlot && strings.EqualFold(slot, "head"):
+				for gc := c.FirstChild; gc != nil; gc = gc.NextSibling {
+					result = append(result, gc)
+				}
+				page.RemoveChild(c)
+			case headTags.Has(c.Data):
+				page.RemoveChild(c)
+				result = append(result, c)
+			}
+		}
+
+		c = next
+	}
+
+	return result
+}
+
+// mergeHead appends nodes to the <head> of doc
+func mergeHead(doc *html.Node, nodes []*html.Node) error {
+	if len(filterChildren(nodes)) == 0 {
+		return nil
+	}
+
+	head := findHeadElement(doc)
+	if head == nil {
+		return fmt.Errorf("layout has no <head>, to merge page's head into")
+	}
+
+	hasTitle := slices.ContainsFunc(nodes, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.Data == "title"
+	})
+
+	if hasTitle {
+		for c := head.FirstChild; c != nil; {
+			next := c.NextSibling
+			if c.Type == html.ElementNode && c.Data == "title" {
+				head.RemoveChild(c)
+			}
+			c = next
+		}
+	}
+
+	for _, n := range nodes {
+		head.AppendChild(cloneNode(n))
+	}
+
+	return nil
+}
+
+// parseSource parses a page (or a layout), with component tags marked with their source positions
+func parseSource(file string, b []byte, delims types.Delims) (*html.Node, error) {
+	if usesLayout(b) {
+		b = headAsSlot(b)
+	}
+
+	n, err := parseWithFragments(bytes.NewReader(markComponentPositions(b)), delims)
+	if err != nil {
+		if file != "" {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// expandPage parses a page (or a layout), expands its components, and applies its layout, if it declares one.
+// layouts is the chain of layouts, being applied so far
+func expandPage(p Params, file string, b []byte, layouts []string) (*html.Node, error) {
+	n, err := parseSource(file, b, p.Delims)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err = parseHTMLAndTranspile(p, file, n, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if page := findLayoutNode(n); page != nil {
+		return applyLayout(p, file, page, layouts)
+	}
+
+	return n, nil
+}
+
+// applyLayout renders the layout, page declares with <Layout name="...">, filled with page's content
+func applyLayout(p Params, file string, page *html.Node, layouts []string) (*html.Node, error) {
+	name, _ := getAttr(page, "name")
+	if name == "" {
+		return nil, componentError(file, page, "", fmt.Errorf("layout name is required, i.e. <Layout name=\"base\">"))[0]
+	}
+
+	if slices.Contains(layouts, name) {
+		return nil, componentError(file, page, "", fmt.Errorf("layout cycle (%s)", strings.Join(append(layouts, name), " → ")))[0]
+	}
+
+	if p.GetLayout == nil {
+		return nil, componentError(file, page, "", fmt.Errorf("unknown layout (%s), layouts are not configured", name))[0]
+	}
+
+	layoutFile, r, err := p.GetLayout(name)
+	if err != nil {
+		return nil, componentError(file, page, "", err)[0]
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := expandPage(p, layoutFile, b, append(slices.Clone(layouts), name))
+	if err != nil {
+		return nil, errors.Join(componentError(file, page, layoutFile, err)...)
+	}
+
+	head := extractHead(page)
+
+	if !slices.ContainsFunc(findSlotPlaceholders(doc), isDefaultSlot) {
+		return nil, componentError(file, page, layoutFile, fmt.Errorf("layout (%s) has no <Children /> slot, for the page content", name))[0]
+	}
+
+	if err := fillSlots(page, doc); err != nil {
+		return nil, componentError(file, page, layoutFile, err)[0]
+	}
+
+	if err := mergeHead(doc, head); err != nil {
+		return nil, componentError(file, page, layoutFile, err)[0]
+	}
+
+	return doc, nil
+}
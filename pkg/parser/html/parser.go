@@ -9,9 +9,13 @@ import (
 	"log/slog"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
 	textTemplate "text/template"
+	"text/template/parse"
 
+	"github.com/nxtcoder17/htmlc/pkg/funcs"
+	"github.com/nxtcoder17/htmlc/pkg/types"
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
 )
@@ -40,35 +44,18 @@ func findHeadElement(n *html.Node) *html.Node {
 	return nil
 }
 
-func findChildrenPlaceholderNode(n *html.Node) *html.Node {
-	if n.Type == html.ElementNode {
-		slog.Debug("find HEAD element", "type", n.Type, "data", n.Data, "attr", n.Attr, "data-atom", n.DataAtom)
-		switch n.Data {
-		case "children":
-			return n
-		}
-	}
-	// Recursively process child nodes
-	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		if n := findChildrenPlaceholderNode(c); n != nil {
-			return n
-		}
-	}
-
-	return nil
-}
-
-func parseHTML(n *html.Node, onTargetNodeFound func(node *html.Node)) error {
+func parseHTML(n *html.Node, elements Elements, onTargetNodeFound func(node *html.Node)) error {
 	if n == nil {
 		return nil
 	}
 
-	if n.DataAtom == atom.Svg {
+	// INFO: svg, and mathml content is kept as is
+	if n.DataAtom == atom.Svg || n.DataAtom == atom.Math {
 		return nil
 	}
 
-	if n.Type == html.ElementNode && !HTMLTags.Has(n.Data) {
-		if strings.ToLower(n.Data) != "children" {
+	if _, _, tag := componentPosition(n); n.Type == html.ElementNode && elements.IsComponent(tag) {
+		if !isSlotPlaceholder(n) && n.Data != layoutTag {
 			// logNode("target-node", n)
 			onTargetNodeFound(n)
 			return nil
@@ -78,19 +65,25 @@ func parseHTML(n *html.Node, onTargetNodeFound func(node *html.Node)) error {
 	// 			fmt.Println("ERR", err, "path", path)
 	// Recursively process child nodes
 	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		parseHTML(c, onTargetNodeFound)
+		parseHTML(c, elements, onTargetNodeFound)
 	}
 
 	return nil
 }
 
-func parseWithFragments(reader io.Reader) (*html.Node, error) {
+// parseWithFragments parses a page, component output, or a fragment of them, where go template actions use delims
+func parseWithFragments(reader io.Reader, delims types.Delims) (*html.Node, error) {
 	b, err := fixSelfClosingTags(reader)
 	if err != nil {
 		return nil, err
 	}
 
-	t := textTemplate.New("t:html:parser")
+	if isDocument(b) {
+		return html.Parse(bytes.NewReader(b))
+	}
+
+	t := textTemplate.New("t:html:parser").Delims(delims.Left, delims.Right)
+	t = t.Funcs(funcs.FuncMap())
 	t = t.Funcs(template.FuncMap{
 		"children": func() string {
 			return ""
@@ -103,16 +96,20 @@ func parseWithFragments(reader io.Reader) (*html.Node, error) {
 		return nil, err
 	}
 
-	if len(t.Templates()) > 1 {
+	// INFO: a source with only `define` blocks (i.e. a component) is its first define block, while a page with content
+	// of its own, keeps its define blocks as is, to be used by its content
+	if len(t.Templates()) > 1 && (t.Tree == nil || parse.IsEmptyTree(t.Tree.Root)) {
 		content := ""
 		for _, mt := range t.Templates() {
 			if mt.Name() != t.Name() {
-				content = mt.Root.String()
+				sb := new(strings.Builder)
+				writeNodes(sb, mt.Root, delims)
+				content = sb.String()
 				break
 			}
 		}
 
-		return parseWithFragments(bytes.NewReader([]byte(content)))
+		return parseWithFragments(bytes.NewReader([]byte(content)), delims)
 	}
 
 	htmlNode := &html.Node{
@@ -166,6 +163,9 @@ func parseWithFragments(reader io.Reader) (*html.Node, error) {
 func htmlAttrsToMap(attrs []html.Attribute) map[string]any {
 	m := make(map[string]any, len(attrs))
 	for _, attr := range attrs {
+		if attr.Key == posAttr || attr.Key == tagAttr {
+			continue
+		}
 		m[attr.Key] = attr.Val
 	}
 
@@ -173,13 +173,75 @@ func htmlAttrsToMap(attrs []html.Attribute) map[string]any {
 }
 
 type Params struct {
+	// FileName is the name of Input, used in error messages
+	FileName     string
 	Input        io.Reader
 	Output       io.Writer
 	Template     *template.Template
 	GetComponent func(name string, attrs map[string]any) (Component, error)
+
+	// GetLayout returns the layout file, and its content, for pages declaring `<Layout name="...">`
+	GetLayout func(name string) (file string, content io.Reader, err error)
+
+	// Delims are the go template delimiters, pages (and layouts) use, empty ones are the default `{{` and `}}`
+	Delims types.Delims
+
+	// Elements decides, which tags are components, and which ones are elements (i.e. custom elements), kept as is
+	Elements Elements
+
+	// MaxDepth is the maximum nesting of components, i.e. 2 for a page using Card, that uses CardBody.
+	// It is not limited when 0, though a component using itself (directly, or via other components) always fails
+	MaxDepth int
 }
 
-var re = regexp.MustCompile(`<([A-Za-z0-9]+)([^>]*)\/>`)
+// writeNodes writes template source of n, like n.String() does, but with delims, as String() always uses `{{` and `}}`
+func writeNodes(sb *strings.Builder, n parse.Node, delims types.Delims) {
+	d := delims.OrDefault()
+
+	writeBranch := func(keyword string, b *parse.BranchNode) {
+		fmt.Fprintf(sb, "%s%s %s%s", d.Left, keyword, b.Pipe, d.Right)
+		writeNodes(sb, b.List, delims)
+		if b.ElseList != nil {
+			fmt.Fprintf(sb, "%selse%s", d.Left, d.Right)
+			writeNodes(sb, b.ElseList, delims)
+		}
+		fmt.Fprintf(sb, "%send%s", d.Left, d.Right)
+	}
+
+	switch node := n.(type) {
+	case *parse.ListNode:
+		for _, c := range node.Nodes {
+			writeNodes(sb, c, delims)
+		}
+	case *parse.TextNode:
+		sb.Write(node.Text)
+	case *parse.ActionNode:
+		fmt.Fprintf(sb, "%s%s%s", d.Left, node.Pipe, d.Right)
+	case *parse.IfNode:
+		writeBranch("if", &node.BranchNode)
+	case *parse.RangeNode:
+		writeBranch("range", &node.BranchNode)
+	case *parse.WithNode:
+		writeBranch("with", &node.BranchNode)
+	case *parse.TemplateNode:
+		if node.Pipe == nil {
+			fmt.Fprintf(sb, "%stemplate %q%s", d.Left, node.Name, d.Right)
+			return
+		}
+		fmt.Fprintf(sb, "%stemplate %q %s%s", d.Left, node.Name, node.Pipe, d.Right)
+	case *parse.BreakNode:
+		fmt.Fprintf(sb, "%sbreak%s", d.Left, d.Right)
+	case *parse.ContinueNode:
+		fmt.Fprintf(sb, "%scontinue%s", d.Left, d.Right)
+	case *parse.CommentNode:
+		// INFO: comments are dropped, like templates parsed without parse.ParseComments
+	default:
+		sb.WriteString(n.String())
+	}
+}
+
+// INFO: tag names could have a namespace, or a hyphen, i.e. <ui:Button />, or <ui:nav-bar />
+var re = regexp.MustCompile(`<([A-Za-z0-9:-]+)([^>]*)\/>`)
 
 func fixSelfClosingTags(r io.Reader) ([]byte, error) {
 	b, err := io.ReadAll(r)
@@ -191,45 +253,71 @@ func fixSelfClosingTags(r io.Reader) ([]byte, error) {
 	return b2, nil
 }
 
-func parseHTMLAndTranspile(n *html.Node, t *template.Template, getComponent func(name string, attrs map[string]any) (Component, error)) (*html.Node, error) {
+// parseHTMLAndTranspile expands all the components in n. expanding are the tags of components, n is being expanded within,
+// i.e. [Card, CardBody] for components used by CardBody, used by Card
+func parseHTMLAndTranspile(p Params, file string, n *html.Node, expanding []string) (*html.Node, error) {
 	var replaceNodes []*html.Node
 	onTargetNodeFound := func(n *html.Node) {
 		replaceNodes = append(replaceNodes, n)
 	}
 
-	if err := parseHTML(n, onTargetNodeFound); err != nil {
+	if err := parseHTML(n, p.Elements, onTargetNodeFound); err != nil {
 		return nil, err
 	}
 
 	headEl := findHeadElement(n)
 
+	var errs []error
+
 	for _, rn := range replaceNodes {
-		component, err := getComponent(rn.Data, htmlAttrsToMap(rn.Attr))
+		_, _, tag := componentPosition(rn)
+		chain := append(slices.Clone(expanding), tag)
+
+		if slices.ContainsFunc(expanding, func(c string) bool { return strings.EqualFold(c, tag) }) {
+			errs = append(errs, componentError(file, rn, "", fmt.Errorf("component cycle (%s)", strings.Join(chain, " → ")))...)
+			continue
+		}
+
+		if p.MaxDepth > 0 && len(chain) > p.MaxDepth {
+			errs = append(errs, componentError(file, rn, "", fmt.Errorf("components are nested deeper than (%d), (%s)", p.MaxDepth, strings.Join(chain, " → ")))...)
+			continue
+		}
+
+		component, err := p.GetComponent(rn.Data, htmlAttrsToMap(rn.Attr))
 		if err != nil {
-			return nil, err
+			errs = append(errs, componentError(file, rn, "", err)...)
+			continue
+		}
+
+		var source string
+		if cs, ok := component.(ComponentSource); ok {
+			source = cs.Source()
 		}
 
 		b := new(bytes.Buffer)
 
 		if err := component.Render(b); err != nil {
-			return nil, err
+			errs = append(errs, componentError(file, rn, source, err)...)
+			continue
 		}
 
 		// logger.Info("debugging", "rendered component",  b.String())
 
-		newNode, err := parseWithFragments(b)
+		newNode, err := parseWithFragments(bytes.NewReader(markComponentTags(b.Bytes())), p.Delims)
 		if err != nil {
-			return nil, err
+			errs = append(errs, componentError(file, rn, source, err)...)
+			continue
 		}
 
-		newNode, err = parseHTMLAndTranspile(newNode, t, getComponent)
+		newNode, err = parseHTMLAndTranspile(p, file, newNode, chain)
 		if err != nil {
-			return nil, err
+			errs = append(errs, componentError(file, rn, source, err)...)
+			continue
 		}
 
 		if verboseDebugging {
 			fmt.Println("------------------------------")
-			renderHTML(os.Stdout, newNode)
+			renderHTML(os.Stdout, newNode, p.Delims)
 			fmt.Println("------------------------------")
 		}
 
@@ -237,52 +325,53 @@ func parseHTMLAndTranspile(n *html.Node, t *template.Template, getComponent func
 		case "head":
 			{
 				if headEl == nil {
-					headEl = rn
+					// INFO: tree has no <head> (i.e. head of a page using a layout), so head's content replaces the component
+					copyChildren(newNode, rn.Parent, rn)
+				} else {
+					copyChildren(newNode, headEl)
 				}
 
-				copyChildren(newNode, headEl)
 				parent := rn.Parent
 				parent.RemoveChild(rn)
 			}
 		default:
 			{
-				// INFO: finds the <Children /> node, and replaces it with the real component children
-				if childrenNode := findChildrenPlaceholderNode(newNode); childrenNode != nil {
-					childparent := childrenNode.Parent
-					copyChildren(rn, childparent, childrenNode)
-					childparent.RemoveChild(childrenNode)
-
-					newNode, err = parseHTMLAndTranspile(newNode, t, getComponent)
-					if err != nil {
-						return nil, err
-					}
-				} else {
-					copyChildren(rn, newNode)
+				// INFO: replaces <Children /> and <Slot /> placeholders, with the real component children
+				if err := fillSlots(rn, newNode); err != nil {
+					errs = append(errs, componentError(file, rn, source, err)...)
+					continue
+				}
 
-					newNode, err = parseHTMLAndTranspile(newNode, t, getComponent)
-					if err != nil {
-						return nil, err
-					}
+				// INFO: children are written where the component is used, so they are not nested within it, i.e. <Card><Card /></Card> is not a cycle
+				newNode, err = parseHTMLAndTranspile(p, file, newNode, expanding)
+				if err != nil {
+					errs = append(errs, componentError(file, rn, source, err)...)
+					continue
 				}
 				replaceNode(rn, newNode)
 			}
 		}
 	}
 
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
 	return n, nil
 }
 
 func Parse(p Params) error {
-	n, err := parseWithFragments(p.Input)
-	// n, err := findEffectiveTree(p.Input)
+	b, err := io.ReadAll(p.Input)
 	if err != nil {
 		return err
 	}
 
-	n2, err := parseHTMLAndTranspile(n, p.Template, p.GetComponent)
+	n, err := expandPage(p, p.FileName, b, nil)
 	if err != nil {
 		return err
 	}
 
-	return renderHTML(p.Output, n2)
+	removePositionAttrs(n)
+
+	return renderHTML(p.Output, n, p.Delims)
 }
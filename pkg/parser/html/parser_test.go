@@ -2,11 +2,16 @@ package html
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	textTemplate "text/template"
 
+	"github.com/nxtcoder17/htmlc/pkg/types"
 	"golang.org/x/net/html"
 )
 
@@ -24,13 +29,52 @@ func Test_parseHTML(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := parseHTML(tt.args.n, tt.args.onTargetNodeFound); (err != nil) != tt.wantErr {
+			if err := parseHTML(tt.args.n, Elements{}, tt.args.onTargetNodeFound); (err != nil) != tt.wantErr {
 				t.Errorf("parseHTML() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
+func Test_writeNodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		tmpl   string
+		delims types.Delims
+		want   string
+	}{
+		{
+			name: "1. default delimiters, match String()",
+			tmpl: `{{ define "x" }}<p>{{/* c */}}{{ if .a }}{{ .b }}{{ else }}{{ range $i := .c }}{{ break }}{{ end }}{{ end }}{{ template "y" . }}</p>{{ end }}`,
+			want: `<p>{{if .a}}{{.b}}{{else}}{{range $i := .c}}{{break}}{{end}}{{end}}{{template "y" .}}</p>`,
+		},
+		{
+			name:   "2. custom delimiters, with default ones in text",
+			tmpl:   `[[ define "x" ]]<p x-text="{{ count }}">[[- with .a ]][[ . ]][[ end ]]</p>[[ end ]]`,
+			delims: types.Delims{Left: "[[", Right: "]]"},
+			want:   `<p x-text="{{ count }}">[[with .a]][[.]][[end]]</p>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := textTemplate.New("t").Delims(tt.delims.Left, tt.delims.Right).Parse(tt.tmpl)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sb := new(strings.Builder)
+			writeNodes(sb, tmpl.Lookup("x").Root, tt.delims)
+			if sb.String() != tt.want {
+				t.Errorf("writeNodes()\n\tgot:  %s\n\twant: %s", sb.String(), tt.want)
+			}
+
+			if tt.delims.IsDefault() && sb.String() != tmpl.Lookup("x").Root.String() {
+				t.Errorf("writeNodes() does not match String(), got %s", sb.String())
+			}
+		})
+	}
+}
+
 func Test_parseWithFragments(t *testing.T) {
 	type args struct {
 		reader io.Reader
@@ -45,7 +89,7 @@ func Test_parseWithFragments(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseWithFragments(tt.args.reader)
+			got, err := parseWithFragments(tt.args.reader, types.Delims{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseWithFragments() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -57,6 +101,24 @@ func Test_parseWithFragments(t *testing.T) {
 	}
 }
 
+// staticComponent renders the same html, irrespective of attributes
+type staticComponent string
+
+func (s staticComponent) Render(w io.Writer) error {
+	_, err := io.WriteString(w, string(s))
+	return err
+}
+
+func staticComponents(components map[string]string) func(name string, attrs map[string]any) (Component, error) {
+	return func(name string, attrs map[string]any) (Component, error) {
+		c, ok := components[name]
+		if !ok {
+			return nil, fmt.Errorf("component not found")
+		}
+		return staticComponent(c), nil
+	}
+}
+
 func TestParse(t *testing.T) {
 	type args struct {
 		p Params
@@ -144,6 +206,214 @@ func TestParse(t *testing.T) {
 `),
 			wantErr: false,
 		},
+		{
+			name: "5. component with default <Children /> slot",
+			args: args{
+				p: Params{
+					Input: bytes.NewReader([]byte(`<div><MyButton><b>Click</b></MyButton></div>`)),
+					GetComponent: staticComponents(map[string]string{
+						"mybutton": `<button class="btn"><Children /></button>`,
+					}),
+				},
+			},
+			wantOutput: []byte(`<div><button class="btn"><b>Click</b></button></div>`),
+		},
+		{
+			name: "6. component with named slots",
+			args: args{
+				p: Params{
+					Input: bytes.NewReader([]byte(`<div><Card><template slot="header"><h1>Title</h1></template><Footer slot>bye</Footer><p>body</p></Card></div>`)),
+					GetComponent: staticComponents(map[string]string{
+						"card": `<section><header><Slot name="header" /></header><main><Children /></main><footer><Slot name="footer" /></footer></section>`,
+					}),
+				},
+			},
+			wantOutput: []byte(`<div><section><header><h1>Title</h1></header><main><p>body</p></main><footer>bye</footer></section></div>`),
+		},
+		{
+			name: "7. component with element filling a named slot, and fallback content for unfilled slots",
+			args: args{
+				p: Params{
+					Input: bytes.NewReader([]byte(`<div><Card><span slot="header">Title</span></Card></div>`)),
+					GetComponent: staticComponents(map[string]string{
+						"card": `<section><Slot name="header">Default Title</Slot><Slot>Empty</Slot></section>`,
+					}),
+				},
+			},
+			wantOutput: []byte(`<div><section><span>Title</span>Empty</section></div>`),
+		},
+		{
+			name: "8. filling a slot, that component does not declare",
+			args: args{
+				p: Params{
+					Input: bytes.NewReader([]byte(`<div><Card><template slot="sidebar">x</template></Card></div>`)),
+					GetComponent: staticComponents(map[string]string{
+						"card": `<section><Children /></section>`,
+					}),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "9. namespaced components",
+			args: args{
+				p: Params{
+					Input: bytes.NewReader([]byte(`<div><ui:Button /><marketing:Button>Buy</marketing:Button></div>`)),
+					GetComponent: staticComponents(map[string]string{
+						"ui:button":        `<button class="ui"></button>`,
+						"marketing:button": `<button class="marketing"><Children /></button>`,
+					}),
+				},
+			},
+			wantOutput: []byte(`<div><button class="ui"></button><button class="marketing">Buy</button></div>`),
+		},
+		{
+			name: "10. component as a child of the same component, is not a cycle",
+			args: args{
+				p: Params{
+					Input: bytes.NewReader([]byte(`<div><Card><Card>x</Card></Card></div>`)),
+					GetComponent: staticComponents(map[string]string{
+						"card": `<section><Children /></section>`,
+					}),
+					MaxDepth: 1,
+				},
+			},
+			wantOutput: []byte(`<div><section><section>x</section></section></div>`),
+		},
+		{
+			name: "11. custom elements, svg and mathml are kept as is, components in custom elements are expanded",
+			args: args{
+				p: Params{
+					Input: bytes.NewReader([]byte(`<div><sl-card><Icon /></sl-card><math><mi>x</mi></math></div>`)),
+					GetComponent: staticComponents(map[string]string{
+						"icon": `<svg><Shape /></svg>`,
+					}),
+				},
+			},
+			wantOutput: []byte(`<div><sl-card><svg><shape></shape></svg></sl-card><math><mi>x</mi></math></div>`),
+		},
+		{
+			name: "12. lowercase svg elements, rendered by a component",
+			args: args{
+				p: Params{
+					Input: bytes.NewReader([]byte(`<div><svg><g><Circle /></g></svg><Text /></div>`)),
+					GetComponent: staticComponents(map[string]string{
+						"text": `<g><text>hi</text></g>`,
+					}),
+				},
+			},
+			wantOutput: []byte(`<div><svg><g><circle></circle></g></svg><g><text>hi</text></g></div>`),
+		},
+		{
+			name: "13. allowed, and denied tags",
+			args: args{
+				p: Params{
+					Input: bytes.NewReader([]byte(`<div><mjml></mjml><ui-card></ui-card></div>`)),
+					GetComponent: staticComponents(map[string]string{
+						"ui-card": `<section></section>`,
+					}),
+					Elements: Elements{Allow: []string{"mjml"}, Deny: []string{"ui-*"}},
+				},
+			},
+			wantOutput: []byte(`<div><mjml></mjml><section></section></div>`),
+		},
+		{
+			name: "14. text looking like tags, in scripts, styles and comments, is kept as is",
+			args: args{
+				p: Params{
+					Input: bytes.NewReader([]byte(`<div><script>for (i=0; i <count ;) {}</script><style>a <b {}</style><!-- old <Thing here> --><Badge /></div>`)),
+					GetComponent: staticComponents(map[string]string{
+						"badge": `<span>b</span>`,
+					}),
+				},
+			},
+			wantOutput: []byte(`<div><script>for (i=0; i <count ;) {}</script><style>a <b {}</style><!-- old <Thing here> --><span>b</span></div>`),
+		},
+		{
+			name: "15. inline scripts, rendered by a component, are kept as is",
+			args: args{
+				p: Params{
+					Input: bytes.NewReader([]byte(`<div><Counter /></div>`)),
+					GetComponent: staticComponents(map[string]string{
+						"counter": `<div><script>for (;i <max ;) { i++ }</script><Badge /></div>`,
+						"badge":   `<span>b</span>`,
+					}),
+				},
+			},
+			wantOutput: []byte(`<div><div><script>for (;i <max ;) { i++ }</script><span>b</span></div></div>`),
+		},
+		{
+			name: "16. slot names are case insensitive",
+			args: args{
+				p: Params{
+					Input: bytes.NewReader([]byte(`<div><Card><template slot="Header">H</template><span slot="FOOTER">F</span></Card></div>`)),
+					GetComponent: staticComponents(map[string]string{
+						"card": `<section><Slot name="header" /><Slot name="Footer" /></section>`,
+					}),
+				},
+			},
+			wantOutput: []byte(`<div><section>H<span>F</span></section></div>`),
+		},
+		{
+			name: "17. native <slot> of web components is kept as is",
+			args: args{
+				p: Params{
+					Input: bytes.NewReader([]byte(`<div><Card><b>x</b></Card></div>`)),
+					GetComponent: staticComponents(map[string]string{
+						"card": `<section><slot name="title"></slot><slot></slot><Children /></section>`,
+					}),
+				},
+			},
+			wantOutput: []byte(`<div><section><slot name="title"></slot><slot></slot><b>x</b></section></div>`),
+		},
+		{
+			name: "18. <html>, and <!doctype> inside comments, and scripts of a fragment",
+			args: args{
+				p: Params{
+					Input:        bytes.NewReader([]byte(`<div><!-- wraps <html> --><script>let s = "<!doctype html>"</script></div>`)),
+					GetComponent: staticComponents(map[string]string{}),
+				},
+			},
+			wantOutput: []byte(`<div><!-- wraps <html> --><script>let s = "<!doctype html>"</script></div>`),
+		},
+		{
+			name: "19. registered components, named like html elements",
+			args: args{
+				p: Params{
+					Input: bytes.NewReader([]byte(`<div><Menu /><Search /><menu><li>x</li></menu><Center /></div>`)),
+					GetComponent: staticComponents(map[string]string{
+						"menu":   `<nav>m</nav>`,
+						"search": `<form>s</form>`,
+					}),
+					Elements: Elements{Registered: registered("Menu", "Search")},
+				},
+			},
+			wantOutput: []byte(`<div><nav>m</nav><form>s</form><menu><li>x</li></menu><center></center></div>`),
+		},
+		{
+			name: "20. page with define blocks of its own, and string literals in actions",
+			args: args{
+				p: Params{
+					Input: bytes.NewReader([]byte(`<div>{{ define "row" }}<Item />{{ end }}<ul>{{ range .Items }}{{ template "row" . }}{{ end }}</ul></div>`)),
+					GetComponent: staticComponents(map[string]string{
+						"item": `<li>{{ . }}</li>`,
+					}),
+				},
+			},
+			wantOutput: []byte(`<div>{{ define "row" }}<li>{{ . }}</li>{{ end }}<ul>{{ range .Items }}{{ template "row" . }}{{ end }}</ul></div>`),
+		},
+		{
+			name: "21. lowercase namespaced components, and self closing custom elements",
+			args: args{
+				p: Params{
+					Input: bytes.NewReader([]byte(`<div><ui:nav-bar /><my-element /></div>`)),
+					GetComponent: staticComponents(map[string]string{
+						"ui:nav-bar": `<nav>n</nav>`,
+					}),
+				},
+			},
+			wantOutput: []byte(`<div><nav>n</nav><my-element></my-element></div>`),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -170,3 +440,200 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+// registered reports tags, registered as components
+func registered(tags ...string) func(tag string) bool {
+	return func(tag string) bool {
+		return slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) })
+	}
+}
+
+func TestElements_IsComponent(t *testing.T) {
+	tests := []struct {
+		name     string
+		elements Elements
+		tag      string
+		want     bool
+	}{
+		{name: "1. html element", tag: "div", want: false},
+		{name: "2. html element, written capitalized", tag: "Button", want: false},
+		{name: "3. obsolete html element", tag: "marquee", want: false},
+		{name: "4. custom element", tag: "turbo-frame", want: false},
+		{name: "5. svg element", tag: "lineargradient", want: false},
+		{name: "6. mathml element", tag: "mfrac", want: false},
+		{name: "7. capitalized svg element is a component", tag: "Text", want: true},
+		{name: "8. component", tag: "Card", want: true},
+		{name: "9. namespaced component", tag: "ui:Button", want: true},
+		{name: "10. allowed tag", elements: Elements{Allow: []string{"mj*"}}, tag: "Mjml", want: false},
+		{name: "11. denied custom element", elements: Elements{Deny: []string{"ui-*"}}, tag: "ui-card", want: true},
+		{name: "12. denied html element", elements: Elements{Deny: []string{"menu"}}, tag: "Menu", want: true},
+		{name: "13. registered component, named like an html element", elements: Elements{Registered: registered("Menu")}, tag: "Menu", want: true},
+		{name: "14. html element, named like a registered component", elements: Elements{Registered: registered("menu")}, tag: "menu", want: false},
+		{name: "15. html element, not a registered component", elements: Elements{Registered: registered("Menu")}, tag: "Search", want: false},
+		{name: "16. allowed tag, named like a registered component", elements: Elements{Allow: []string{"menu"}, Registered: registered("Menu")}, tag: "Menu", want: false},
+		{name: "17. lowercase namespaced component, with a hyphen", tag: "ui:nav-bar", want: true},
+		{name: "18. lowercase namespaced component, named like an html element", tag: "ui:menu", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.elements.IsComponent(tt.tag); got != tt.want {
+				t.Errorf("IsComponent(%s) = %v, want %v", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		components map[string]string
+		maxDepth   int
+		wantErrs   []string
+	}{
+		{
+			name:     "1. unknown components are reported with their position",
+			input:    "<div>\n  <Foo />\n  <span><Bar a=\"1\"></Bar></span>\n</div>",
+			wantErrs: []string{"page.html:2:3: <Foo>: component not found", "page.html:3:9: <Bar>: component not found"},
+		},
+		{
+			name:  "2. unknown component, used inside another component",
+			input: "<div>\n<Card></Card>\n</div>",
+			components: map[string]string{
+				"card": `<section><Missing /></section>`,
+			},
+			wantErrs: []string{"page.html:2:1: <Card>: <Missing>: component not found"},
+		},
+		{
+			name:  "3. component using itself",
+			input: "<div>\n<Card></Card>\n</div>",
+			components: map[string]string{
+				"card": `<section><Card /></section>`,
+			},
+			wantErrs: []string{"page.html:2:1: <Card>: <Card>: component cycle (Card → Card)"},
+		},
+		{
+			name:  "4. component using itself, via another component",
+			input: "<div>\n<Card></Card>\n</div>",
+			components: map[string]string{
+				"card":     `<section><CardBody /></section>`,
+				"cardbody": `<div><Card></Card></div>`,
+			},
+			wantErrs: []string{"page.html:2:1: <Card>: <CardBody>: <Card>: component cycle (Card → CardBody → Card)"},
+		},
+		{
+			name:  "5. components nested deeper than max depth",
+			input: "<div>\n<Card></Card>\n</div>",
+			components: map[string]string{
+				"card":     `<section><CardBody /></section>`,
+				"cardbody": `<div><Icon /></div>`,
+				"icon":     `<i></i>`,
+			},
+			maxDepth: 2,
+			wantErrs: []string{"page.html:2:1: <Card>: <CardBody>: <Icon>: components are nested deeper than (2), (Card → CardBody → Icon)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Parse(Params{
+				FileName:     "page.html",
+				Input:        bytes.NewReader([]byte(tt.input)),
+				Output:       new(bytes.Buffer),
+				GetComponent: staticComponents(tt.components),
+				MaxDepth:     tt.maxDepth,
+			})
+			if err == nil {
+				t.Fatalf("Parse() expected errors, got none")
+			}
+
+			got := FlattenErrors(err)
+			if len(got) != len(tt.wantErrs) {
+				t.Fatalf("Parse() got %d errors, want %d\n%s", len(got), len(tt.wantErrs), err)
+			}
+
+			for i := range got {
+				if got[i].Error() != tt.wantErrs[i] {
+					t.Errorf("error did not match:\n\n\twant: %s\n\tgot: %s\n\n", tt.wantErrs[i], got[i])
+				}
+
+				var e *Error
+				if !errors.As(got[i], &e) {
+					t.Errorf("expected error of type *Error, got %T", got[i])
+				}
+			}
+		})
+	}
+}
+
+func staticLayouts(layouts map[string]string) func(name string) (string, io.Reader, error) {
+	return func(name string) (string, io.Reader, error) {
+		l, ok := layouts[name]
+		if !ok {
+			return "", nil, fmt.Errorf("unknown layout (%s)", name)
+		}
+		return name + ".html", bytes.NewReader([]byte(l)), nil
+	}
+}
+
+func TestParse_layouts(t *testing.T) {
+	layouts := map[string]string{
+		"base":   `<!DOCTYPE html><html><head><title>Base</title><meta charset="UTF-8"/></head><body><Slot name="nav">default nav</Slot><main><Children /></main></body></html>`,
+		"admin":  `<Layout name="base"><template slot="nav"><nav>admin</nav></template><div class="admin"><Children /></div></Layout>`,
+		"cyclic": `<Layout name="cyclic"><Children /></Layout>`,
+	}
+
+	tests := []struct {
+		name       string
+		input      string
+		wantOutput string
+		wantErr    bool
+	}{
+		{
+			name: "1. page with a layout, and head merged into layout's head",
+			input: `<Layout name="base">
+  <head><title>Home</title><link rel="stylesheet" href="/style.css"/></head>
+  <h1>Hello</h1>
+</Layout>`,
+			wantOutput: `<!DOCTYPE html><html><head><meta charset="UTF-8"/><title>Home</title><link rel="stylesheet" href="/style.css"/></head><body>default nav<main>
+  
+  <h1>Hello</h1>
+</main></body></html>`,
+		},
+		{
+			name:       "2. page with nested layouts",
+			input:      `<Layout name="admin"><h1>Users</h1></Layout>`,
+			wantOutput: `<!DOCTYPE html><html><head><title>Base</title><meta charset="UTF-8"/></head><body><nav>admin</nav><main><div class="admin"><h1>Users</h1></div></main></body></html>`,
+		},
+		{
+			name:    "3. layouts including each other",
+			input:   `<Layout name="cyclic"><h1>Users</h1></Layout>`,
+			wantErr: true,
+		},
+		{
+			name:    "4. unknown layout",
+			input:   `<Layout name="unknown"><h1>Users</h1></Layout>`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			err := Parse(Params{
+				FileName:     "page.html",
+				Input:        bytes.NewReader([]byte(tt.input)),
+				Output:       out,
+				GetComponent: staticComponents(nil),
+				GetLayout:    staticLayouts(layouts),
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := strings.TrimSpace(out.String()); got != tt.wantOutput {
+				t.Errorf("output did not match:\n\n\twant: %s\n\tgot: %s\n\n", tt.wantOutput, got)
+			}
+		})
+	}
+}
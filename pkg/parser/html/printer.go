@@ -1,15 +1,33 @@
 package html
 
 import (
+	"bytes"
 	"io"
+	"log/slog"
+	"regexp"
 
+	"github.com/nxtcoder17/htmlc/pkg/types"
 	"golang.org/x/net/html"
 )
 
-func renderHTML(w io.Writer, n *html.Node) error {
+// renderHTML renders n, with go template actions (within delims) as written, as rendering html escapes quotes
+// in text, i.e. {{ template "row" . }} would become {{ template &#34;row&#34; . }}
+func renderHTML(w io.Writer, n *html.Node, delims types.Delims) error {
 	if n == nil {
 		return nil
 	}
-	logger.Debug("RENDERING html")
-	return html.Render(w, n)
+	slog.Debug("RENDERING html")
+
+	b := new(bytes.Buffer)
+	if err := html.Render(b, n); err != nil {
+		return err
+	}
+
+	d := delims.OrDefault()
+	actionRe := regexp.MustCompile(`(?s)` + regexp.QuoteMeta(d.Left) + `.*?` + regexp.QuoteMeta(d.Right))
+
+	_, err := w.Write(actionRe.ReplaceAllFunc(b.Bytes(), func(action []byte) []byte {
+		return []byte(html.UnescapeString(string(action)))
+	}))
+	return err
 }
@@ -0,0 +1,172 @@
+package html
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Slots
+//
+// A component declares placeholders for the content passed to it
+//   - `<Children />` or `<Slot />`, for the default slot
+//   - `<Slot name="header" />`, for a named slot
+//
+// Content inside a placeholder is its fallback, and is rendered when the slot is not filled.
+//
+// At call site, a named slot is filled with
+//   - `<template slot="header">...</template>`, its children fill the slot
+//   - `<Header slot>...</Header>`, its children fill the slot named after the tag
+//   - `<div slot="header">...</div>`, the element itself fills the slot
+//
+// everything else fills the default slot. Slot names are case insensitive.
+//
+// A `<slot>` written in lowercase is the native slot of web components, and is kept as is.
+
+const (
+	childrenTag = "children"
+	slotTag     = "slot"
+)
+
+func getAttr(n *html.Node, key string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+func removeAttr(attrs []html.Attribute, key string) []html.Attribute {
+	result := make([]html.Attribute, 0, len(attrs))
+	for _, attr := range attrs {
+		if attr.Key != key {
+			result = append(result, attr)
+		}
+	}
+	return result
+}
+
+func isSlotPlaceholder(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+
+	_, _, tag := componentPosition(n)
+	return n.Data == childrenTag || (n.Data == slotTag && tag != slotTag)
+}
+
+// isDefaultSlot reports whether slot placeholder n, is for the default slot
+func isDefaultSlot(n *html.Node) bool {
+	name, _ := getAttr(n, "name")
+	return n.Data == childrenTag || name == ""
+}
+
+// collectSlots splits children of a component node, into named slots content and default slot content
+func collectSlots(n *html.Node) (map[string][]*html.Node, []*html.Node) {
+	named := make(map[string][]*html.Node)
+	var rest []*html.Node
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		name, ok := "", false
+		if c.Type == html.ElementNode {
+			name, ok = getAttr(c, slotTag)
+		}
+
+		if !ok {
+			rest = append(rest, c)
+			continue
+		}
+
+		name = strings.ToLower(name)
+		if name == "" || c.Data == "template" {
+			if name == "" {
+				name = c.Data
+			}
+			for gc := c.FirstChild; gc != nil; gc = gc.NextSibling {
+				named[name] = append(named[name], gc)
+			}
+			continue
+		}
+
+		nc := cloneNode(c)
+		nc.Attr = removeAttr(nc.Attr, slotTag)
+		named[name] = append(named[name], nc)
+	}
+
+	return named, rest
+}
+
+func findSlotPlaceholders(n *html.Node) []*html.Node {
+	var result []*html.Node
+	if isSlotPlaceholder(n) {
+		result = append(result, n)
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		result = append(result, findSlotPlaceholders(c)...)
+	}
+
+	return result
+}
+
+// fillSlots replaces slot placeholders in rendered component (newNode), with the slots content from component node (rn)
+func fillSlots(rn *html.Node, newNode *html.Node) error {
+	named, rest := collectSlots(rn)
+	filled := make(map[string]bool, len(named))
+
+	hasDefaultSlot := false
+
+	for _, p := range findSlotPlaceholders(newNode) {
+		var content []*html.Node
+
+		if isDefaultSlot(p) {
+			hasDefaultSlot = true
+			content = rest
+		} else {
+			name, _ := getAttr(p, "name")
+			name = strings.ToLower(name)
+			content = named[name]
+			filled[name] = true
+		}
+
+		if len(filterChildren(content)) == 0 {
+			// INFO: slot is not filled, so rendering its fallback content
+			content = nil
+			for c := p.FirstChild; c != nil; c = c.NextSibling {
+				content = append(content, c)
+			}
+		}
+
+		if p.Parent == nil {
+			return fmt.Errorf("slot placeholder <%s> must be wrapped in an element", p.Data)
+		}
+
+		for _, c := range content {
+			p.Parent.InsertBefore(cloneNode(c), p)
+		}
+		p.Parent.RemoveChild(p)
+	}
+
+	if !hasDefaultSlot {
+		for _, c := range rest {
+			newNode.AppendChild(cloneNode(c))
+		}
+	}
+
+	var unknown []string
+	for name := range named {
+		if !filled[name] {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown slots (%s)", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package html
 
 import (
 	"io"
+	"log/slog"
 	"strings"
 
 	"golang.org/x/net/html"
@@ -44,9 +45,9 @@ func filterChildren(nodes []*html.Node) []*html.Node {
 }
 
 func copyChildren(oldNode, newNode *html.Node, insertBefore ...*html.Node) {
-	logger.Debug("HERE", "oldnode", oldNode.Data, "new node", newNode.Data)
+	slog.Debug("HERE", "oldnode", oldNode.Data, "new node", newNode.Data)
 	for c := oldNode.FirstChild; c != nil; c = c.NextSibling {
-		logger.Debug("HERE", "oldnode.FirstChild", c == nil, "new node", newNode == nil)
+		slog.Debug("HERE", "oldnode.FirstChild", c == nil, "new node", newNode == nil)
 		nc := html.Node{
 			FirstChild: c.FirstChild,
 			LastChild:  c.LastChild,
@@ -65,6 +66,23 @@ func copyChildren(oldNode, newNode *html.Node, insertBefore ...*html.Node) {
 	}
 }
 
+// cloneNode deep copies a node, the copy is detached from its parent and siblings
+func cloneNode(n *html.Node) *html.Node {
+	nc := &html.Node{
+		Type:      n.Type,
+		DataAtom:  n.DataAtom,
+		Data:      n.Data,
+		Namespace: n.Namespace,
+		Attr:      append([]html.Attribute(nil), n.Attr...),
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nc.AppendChild(cloneNode(c))
+	}
+
+	return nc
+}
+
 // replaceNode replaces a node in the DOM
 func replaceNode(oldNode, newNode *html.Node) {
 	parent := oldNode.Parent
@@ -80,7 +98,7 @@ func replaceNode(oldNode, newNode *html.Node) {
 	switch newNode.Data {
 	case "fragment":
 		{
-			logger.Info("parent to fragment is", "node", parent.Data)
+			slog.Info("parent to fragment is", "node", parent.Data)
 			copyChildren(newNode, parent)
 		}
 	default:
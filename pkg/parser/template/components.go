@@ -0,0 +1,517 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	htmlTemplate "html/template"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	textTemplate "text/template"
+
+	"github.com/nxtcoder17/htmlc/pkg/funcs"
+	fn "github.com/nxtcoder17/htmlc/pkg/functions"
+	html_parser "github.com/nxtcoder17/htmlc/pkg/parser/html"
+	"github.com/nxtcoder17/htmlc/pkg/types"
+)
+
+// Components is an in-memory registry of component templates.
+//
+// It does, what the generated components package does in its init(), i.e. parses
+// every component template into a single template (per namespace), and keeps a lookup of components
+// keyed by their lowercased struct name (i.e. button, or ui:button in ui namespace), so that pages
+// could be expanded without generating and compiling any go code
+type Components struct {
+	// Template is the template, html components of the default namespace are parsed into, it is nil for text components
+	Template *htmlTemplate.Template
+
+	// text is true, for text components
+	text bool
+
+	// templates maps a (lowercased) namespace, to the template its components are parsed into, so that components
+	// with the same name, in different namespaces, do not replace each other
+	templates map[string]templateExecutor
+
+	// Namespace is the namespace of components being parsed, i.e. ui for <ui:Button>, empty one is the default namespace.
+	// Components of different directories could use different ones, by setting it before ParseDir
+	Namespace string
+
+	// MaxDepth is the maximum nesting of text components, using other components with `component` func, it is
+	// defaultMaxDepth when 0. A component could use itself (directly, or via other components), as it renders with data
+	MaxDepth int
+
+	// Strict makes GetComponent report attributes, that a component does not declare (unless it renders `{{.props}}`),
+	// and attribute values that do not match their `@param` type, i.e. count="abc" for an int param
+	Strict bool
+
+	// Delims are the go template delimiters, of component templates being parsed, empty ones are the default `{{` and `}}`.
+	// Components of different directories could use different ones, by setting it before ParseDir
+	Delims types.Delims
+
+	structs map[string]Struct
+
+	// files maps a component file, to the (lowercased) names of components defined in it
+	files map[string][]string
+
+	// sources maps a (lowercased) component name, to the file it is defined in
+	sources map[string]string
+}
+
+func NewComponents() *Components {
+	c := newComponents(false)
+	c.Template = c.template("").(*htmlTemplate.Template)
+	return c
+}
+
+// NewTextComponents is NewComponents, for text components (i.e. email bodies, config files), they are parsed with
+// text/template, so their output is not HTML escaped.
+//
+// A text component could use other components, with `{{ component "Name" "key" .value }}`
+func NewTextComponents() *Components {
+	return newComponents(true)
+}
+
+func newComponents(text bool) *Components {
+	return &Components{
+		text:      text,
+		templates: make(map[string]templateExecutor),
+		structs:   make(map[string]Struct),
+		files:     make(map[string][]string),
+		sources:   make(map[string]string),
+	}
+}
+
+// template returns the template, components of (lowercased) namespace are parsed into
+func (c *Components) template(namespace string) templateExecutor {
+	if t, ok := c.templates[namespace]; ok {
+		return t
+	}
+
+	name := "template:components"
+	if namespace != "" {
+		name += ":" + namespace
+	}
+
+	var t templateExecutor = htmlTemplate.New(name).Funcs(funcs.FuncMap())
+	if c.text {
+		t = textTemplate.New(name).Funcs(funcs.FuncMap()).Funcs(textTemplate.FuncMap{
+			"component": func(name string, kv ...any) (string, error) {
+				return c.renderComponent(&renderContext{}, name, kv...)
+			},
+		})
+	}
+
+	c.templates[namespace] = t
+	return t
+}
+
+// componentKey returns the key, a component is looked up with, i.e. ui:button for Button in ui namespace
+func componentKey(namespace string, name string) string {
+	if namespace == "" {
+		return strings.ToLower(name)
+	}
+	return strings.ToLower(namespace + ":" + name)
+}
+
+// defaultMaxDepth is the maximum nesting of text components, when it is not set. A component using itself, with data
+// that ends the recursion (i.e. a tree), renders fine, one that never ends it fails with the chain of components
+const defaultMaxDepth = 100
+
+// renderContext is the state of rendering a text component, along with the components it uses, it is not kept in
+// Components, so that components could be rendered (i.e. for different pages) at the same time
+type renderContext struct {
+	// rendering are the names of text components, being rendered, i.e. [Card, CardBody] while CardBody (used by Card) renders
+	rendering []string
+
+	// uses, when set, is called with (lowercased) name of every component, rendered with `component` func
+	uses func(name string)
+}
+
+// renderComponent renders component name, with attributes as key, value pairs, it is `component` func of text components
+func (c *Components) renderComponent(ctx *renderContext, name string, kv ...any) (string, error) {
+	if len(kv)%2 != 0 {
+		return "", fmt.Errorf("component (%s): attributes must be key, value pairs", name)
+	}
+
+	attrs := make(map[string]any, len(kv)/2)
+	for i := 0; i < len(kv); i += 2 {
+		k, ok := kv[i].(string)
+		if !ok {
+			return "", fmt.Errorf("component (%s): attribute name must be a string, got %T", name, kv[i])
+		}
+		attrs[k] = kv[i+1]
+	}
+
+	maxDepth := c.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = defaultMaxDepth
+	}
+
+	chain := append(slices.Clone(ctx.rendering), name)
+	if len(chain) > maxDepth {
+		return "", fmt.Errorf("components are nested deeper than (%d), (%s)", maxDepth, strings.Join(chain, " → "))
+	}
+
+	if ctx.uses != nil {
+		ctx.uses(LookupName(name))
+	}
+
+	component, err := c.getComponent(LookupName(name), attrs, ctx)
+	if err != nil {
+		return "", err
+	}
+
+	b := new(strings.Builder)
+	if err := component.Render(b); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// LookupName returns the name, a component (i.e. email/Greeting, or ui-card) is looked up with, i.e. emailgreeting
+func LookupName(name string) string {
+	return strings.ToLower(strings.NewReplacer("/", "", "-", "", ".", "").Replace(name))
+}
+
+// ParseDir parses all the component templates in dir, matching patterns
+func (c *Components) ParseDir(dir string, patterns []string) error {
+	if patterns == nil {
+		patterns = []string{"*.html"}
+	}
+
+	listings, err := fn.RecursiveLs(dir, patterns)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, item := range listings {
+		slog.Debug("components | listings", "item", item)
+		if err := c.ParseFile(filepath.Join(dir, item)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ParseFile parses a component file, components without a `define` block are named after the file
+func (c *Components) ParseFile(file string) error {
+	input, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	base := filepath.Base(file)
+	base = toFieldName(base[:len(base)-len(filepath.Ext(base))])
+
+	names, err := c.parse(string(input), base, file)
+	if err != nil {
+		return fileErrors(file, err)
+	}
+
+	c.files[file] = names
+	return nil
+}
+
+// fileErrors rewrites every error in err (which could be joined errors), from `template: t:parser:<line>: <msg>`
+// into `<file>:<line>: <msg>`
+func fileErrors(file string, err error) error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []error
+		for _, e := range joined.Unwrap() {
+			errs = append(errs, fileErrors(file, e))
+		}
+		return errors.Join(errs...)
+	}
+
+	if rest, ok := strings.CutPrefix(err.Error(), fmt.Sprintf("template: %s:", fileParserTemplateName)); ok {
+		return fmt.Errorf("%s:%s", file, rest)
+	}
+	return fmt.Errorf("%s: %w", file, err)
+}
+
+// Structs returns structs of all the components, sorted by name, structs of namespaced components are prefixed
+// with their namespace, i.e. UiButton for Button in ui namespace
+func (c *Components) Structs() []Struct {
+	result := make([]Struct, 0, len(c.structs))
+	for key, s := range c.structs {
+		if namespace, _, ok := strings.Cut(key, ":"); ok {
+			s.Name = generateStructName(namespace) + s.Name
+		}
+		s.Key = key
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// FileComponents returns (lowercased) names of components defined in file
+func (c *Components) FileComponents(file string) []string {
+	return c.files[file]
+}
+
+// Parse parses a component template, defaultStructName is used when template does not have any `define` blocks
+func (c *Components) Parse(input string, defaultStructName string) error {
+	_, err := c.parse(input, defaultStructName, "")
+	return err
+}
+
+func (c *Components) parse(input string, defaultStructName string, file string) ([]string, error) {
+	fp, err := NewFileParser(input, defaultStructName, c.Delims)
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, structs, err := fp.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := strings.ToLower(c.Namespace)
+
+	// INFO: duplicates are reported before parsing, as parsing would replace the existing component's template
+	var errs []error
+	seen := make(map[string]struct{}, len(structs))
+	for _, s := range structs {
+		key := componentKey(namespace, s.Name)
+
+		name := s.Name
+		if c.Namespace != "" {
+			name = c.Namespace + ":" + s.Name
+		}
+
+		if other, ok := c.structs[key]; ok {
+			errs = append(errs, fmt.Errorf("duplicate component (%s), it is already defined in (%s)", name, c.source(key, other)))
+		}
+		if _, ok := seen[key]; ok {
+			errs = append(errs, fmt.Errorf("duplicate component (%s), it is defined more than once", name))
+		}
+		seen[key] = struct{}{}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	switch t := c.template(namespace).(type) {
+	case *textTemplate.Template:
+		_, err = t.Delims(c.Delims.Left, c.Delims.Right).Parse(fp.Content)
+	case *htmlTemplate.Template:
+		_, err = t.Delims(c.Delims.Left, c.Delims.Right).Parse(fp.Content)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(structs))
+	for _, s := range structs {
+		key := componentKey(namespace, s.Name)
+		c.structs[key] = s
+		c.sources[key] = file
+		names = append(names, key)
+	}
+
+	return names, nil
+}
+
+// HasComponent reports whether component name (i.e. ui:Button, or email/Greeting) is registered, it's signature
+// matches [html_parser.Elements.Registered]
+func (c *Components) HasComponent(name string) bool {
+	_, ok := c.structs[LookupName(name)]
+	return ok
+}
+
+// GetComponent builds a component, with attrs, it's signature matches [html_parser.Params.GetComponent]
+func (c *Components) GetComponent(name string, attrs map[string]any) (html_parser.Component, error) {
+	return c.getComponent(name, attrs, nil)
+}
+
+// GetComponentUses is GetComponent, where uses is called with (lowercased) name of every text component, the component
+// renders with `component` func, either directly or via other components
+func (c *Components) GetComponentUses(name string, attrs map[string]any, uses func(name string)) (html_parser.Component, error) {
+	return c.getComponent(name, attrs, &renderContext{uses: uses})
+}
+
+// getComponent builds a component, with attrs, a text component is rendered within ctx, or a new one when it is nil
+func (c *Components) getComponent(name string, attrs map[string]any, ctx *renderContext) (html_parser.Component, error) {
+	// INFO: a (denied) custom element tag, i.e. <ui-card> is looked up as UiCard
+	key := LookupName(name)
+	s, ok := c.structs[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown component (%s)", name)
+	}
+
+	namespace, _, ok := strings.Cut(key, ":")
+	if !ok {
+		namespace = ""
+	}
+
+	source := c.source(key, s)
+
+	var errs []error
+
+	known := make(map[string]any, len(s.Fields)+1)
+	for _, f := range s.Fields {
+		v, ok := attrs[f.JsonName]
+		if !ok && f.Default != "" {
+			// INFO: default value is already validated, while parsing the component
+			v, _ = f.DefaultValue()
+			ok = true
+		}
+
+		if f.Required && (!ok || (f.Type == "string" && v == "")) {
+			errs = append(errs, &html_parser.PropError{Component: s.Name, Source: source, Field: f.JsonName, Rule: "required"})
+		}
+		known[f.JsonName] = v
+
+		if str, isString := v.(string); len(f.Enum) > 0 && str != "" && isString && !slices.Contains(f.EnumValues(), str) {
+			errs = append(errs, &html_parser.PropError{Component: s.Name, Source: source, Field: f.JsonName, Rule: "oneof", Err: fmt.Errorf("expected one of (%s), got %q", strings.Join(f.EnumValues(), ", "), str)})
+		}
+
+		if c.Strict && ok {
+			if err := checkLiteral(f.Type, v); err != nil {
+				errs = append(errs, &html_parser.PropError{Component: s.Name, Source: source, Field: f.JsonName, Rule: "type", Err: err})
+			}
+		}
+	}
+
+	if c.Strict && !s.UsesProps {
+		var unknown []string
+		for k := range attrs {
+			if _, ok := known[k]; !ok {
+				unknown = append(unknown, k)
+			}
+		}
+		sort.Strings(unknown)
+
+		for _, k := range unknown {
+			errs = append(errs, &html_parser.PropError{Component: s.Name, Source: source, Field: k, Rule: "unknown", Err: fmt.Errorf("not declared by component, and component does not render {{.props}}")})
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var unknown []string
+	for k, v := range attrs {
+		if _, ok := known[k]; !ok {
+			unknown = append(unknown, fmt.Sprintf("%s=%q", k, v))
+		}
+	}
+	sort.Strings(unknown)
+
+	if !c.text {
+		known["props"] = htmlTemplate.HTMLAttr(strings.Join(unknown, " "))
+		return &component{t: c.templates[namespace], name: s.FromTemplate, source: source, raw: known}, nil
+	}
+
+	known["props"] = strings.Join(unknown, " ")
+
+	display := s.FromTemplate
+	if namespace != "" {
+		display = namespace + ":" + s.FromTemplate
+	}
+	return &component{t: c.templates[namespace], name: s.FromTemplate, source: source, raw: known, components: c, display: display, ctx: ctx}, nil
+}
+
+// checkLiteral checks that an attribute value written in html (i.e. a string), could be decoded into a param of typ.
+// values of types, that can not be written as literals, are not checked
+func checkLiteral(typ string, v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return nil
+	}
+
+	typ = strings.TrimPrefix(typ, "*")
+
+	var err error
+	switch typ {
+	case "int", "int8", "int16", "int32", "int64":
+		_, err = strconv.ParseInt(str, 10, intBitSize(typ))
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		_, err = strconv.ParseUint(str, 10, intBitSize(typ))
+	case "float32", "float64":
+		_, err = strconv.ParseFloat(str, intBitSize(typ))
+	case "bool":
+		// INFO: a boolean attribute without any value (i.e. <Input disabled />) is valid
+		if str != "" {
+			_, err = strconv.ParseBool(str)
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("expected %s, got %q", typ, str)
+	}
+	return nil
+}
+
+// intBitSize returns bit size of a numeric type, i.e. 32 for int32, and 0 for int
+func intBitSize(typ string) int {
+	n, _ := strconv.Atoi(strings.TrimLeft(typ, "intufloa"))
+	return n
+}
+
+// source describes where a component (with key) is defined, i.e. its file and `define` name
+func (c *Components) source(key string, s Struct) string {
+	if file := c.sources[key]; file != "" {
+		return fmt.Sprintf("%s, define %q", file, s.FromTemplate)
+	}
+	return fmt.Sprintf("define %q", s.FromTemplate)
+}
+
+// templateExecutor is either an html, or a text template
+type templateExecutor interface {
+	ExecuteTemplate(w io.Writer, name string, data any) error
+}
+
+type component struct {
+	t      templateExecutor
+	name   string
+	source string
+	raw    map[string]any
+
+	// components (for text components) renders components, the component uses within ctx, display is the name,
+	// it is tracked with in ctx
+	components *Components
+	display    string
+	ctx        *renderContext
+}
+
+func (c *component) Source() string {
+	return c.source
+}
+
+var _ html_parser.ComponentSource = (*component)(nil)
+
+func (c *component) Render(w io.Writer) error {
+	cs := c.components
+	if cs == nil {
+		return c.t.ExecuteTemplate(w, c.name, c.raw)
+	}
+
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = &renderContext{}
+	}
+	ctx.rendering = append(ctx.rendering, c.display)
+	defer func() { ctx.rendering = ctx.rendering[:len(ctx.rendering)-1] }()
+
+	// INFO: text component executes a clone of its template, with `component` func rendering within ctx
+	t, err := c.t.(*textTemplate.Template).Clone()
+	if err != nil {
+		return err
+	}
+	t.Funcs(textTemplate.FuncMap{
+		"component": func(name string, kv ...any) (string, error) {
+			return cs.renderComponent(ctx, name, kv...)
+		},
+	})
+
+	return t.ExecuteTemplate(w, c.name, c.raw)
+}
@@ -0,0 +1,485 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	html_parser "github.com/nxtcoder17/htmlc/pkg/parser/html"
+	"github.com/nxtcoder17/htmlc/pkg/types"
+)
+
+func TestComponents_GetComponent(t *testing.T) {
+	type args struct {
+		name  string
+		attrs map[string]any
+	}
+	tests := []struct {
+		name         string
+		components   string
+		args         args
+		wantOutput   string
+		wantParseErr bool
+		wantErr      bool
+	}{
+		{
+			name: "1. component with known and unknown attributes",
+			components: `{{- define "component/Input" }}
+{{- /* @param class? string */}}
+{{- /* @param label string */}}
+<label class="{{.class}}" {{.props}}>{{.label}}</label>
+{{- end }}`,
+			args: args{
+				name:  "componentinput",
+				attrs: map[string]any{"label": "Email", "id": "email", "for": "email"},
+			},
+			wantOutput: `<label class="" for="email" id="email">Email</label>`,
+		},
+		{
+			name: "2. component without a define block, uses default name",
+			components: `{{- /* @param label string */}}
+<span>{{.label}}</span>`,
+			args: args{
+				name:  "Sample",
+				attrs: map[string]any{"label": "hi"},
+			},
+			wantOutput: `<span>hi</span>`,
+		},
+		{
+			name: "3. missing required attribute",
+			components: `{{- define "Button" }}
+{{- /* @param label string */}}
+<button>{{.label}}</button>
+{{- end }}`,
+			args: args{
+				name:  "button",
+				attrs: map[string]any{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "4. missing attributes, with default values",
+			components: `{{- define "Badge" }}
+{{- /* @param size? string = "md" */}}
+{{- /* @param count int = 1 */}}
+{{- /* @param rounded? bool = true */}}
+<span class="badge-{{.size}}" data-rounded="{{.rounded}}">{{.count}}</span>
+{{- end }}`,
+			args: args{
+				name:  "badge",
+				attrs: map[string]any{"count": "3"},
+			},
+			wantOutput: `<span class="badge-md" data-rounded="true">3</span>`,
+		},
+		{
+			name: "5. invalid default value",
+			components: `{{- define "Badge" }}
+{{- /* @param count int = "one" */}}
+<span>{{.count}}</span>
+{{- end }}`,
+			args: args{
+				name:  "badge",
+				attrs: map[string]any{},
+			},
+			wantParseErr: true,
+		},
+		{
+			name:       "6. unknown component",
+			components: `{{- define "Button" }}<button></button>{{- end }}`,
+			args: args{
+				name:  "PrimaryButton",
+				attrs: map[string]any{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "7. enum attribute, with an allowed value",
+			components: `{{- define "Badge" }}
+{{- /* @param variant? "primary"|"secondary"|"dark-blue" = "primary" */}}
+<span class="badge-{{.variant}}"></span>
+{{- end }}`,
+			args: args{
+				name:  "badge",
+				attrs: map[string]any{"variant": "dark-blue"},
+			},
+			wantOutput: `<span class="badge-dark-blue"></span>`,
+		},
+		{
+			name: "8. enum attribute, with a value that is not allowed",
+			components: `{{- define "Badge" }}
+{{- /* @param variant? "primary"|"secondary" */}}
+<span class="badge-{{.variant}}"></span>
+{{- end }}`,
+			args: args{
+				name:  "badge",
+				attrs: map[string]any{"variant": "dangr"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "9. enum default value, that is not allowed",
+			components: `{{- define "Badge" }}
+{{- /* @param variant? "primary"|"secondary" = "danger" */}}
+<span class="badge-{{.variant}}"></span>
+{{- end }}`,
+			args: args{
+				name:  "badge",
+				attrs: map[string]any{},
+			},
+			wantParseErr: true,
+		},
+		{
+			name: "10. component using template functions",
+			components: `{{- define "Greeting" }}
+{{- /* @param name? string */}}
+<p>{{ .name | default "guest" | title }}</p>
+{{- end }}`,
+			args: args{
+				name:  "greeting",
+				attrs: map[string]any{},
+			},
+			wantOutput: `<p>Guest</p>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewComponents()
+			if err := c.Parse(tt.components, "Sample"); (err != nil) != tt.wantParseErr {
+				t.Fatalf("Parse() error = %v, wantParseErr %v", err, tt.wantParseErr)
+			}
+
+			if tt.wantParseErr {
+				return
+			}
+
+			component, err := c.GetComponent(tt.args.name, tt.args.attrs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetComponent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			b := new(bytes.Buffer)
+			if err := component.Render(b); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+
+			if got := string(bytes.TrimSpace(b.Bytes())); got != tt.wantOutput {
+				t.Errorf("output did not match:\n\n\twant: %s\n\tgot: %s\n\n", tt.wantOutput, got)
+			}
+		})
+	}
+}
+
+func TestComponents_GetComponent_delims(t *testing.T) {
+	c := NewComponents()
+	c.Delims = types.Delims{Left: "[[", Right: "]]"}
+	if err := c.Parse(`[[- define "Counter" ]]
+[[- /* @param start int -- initial count */]]
+<div x-data="{ count: [[.start]] }"><span>{{ count }}</span></div>
+[[- end ]]`, "Sample"); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if fields := c.structs["counter"].Fields; len(fields) != 1 || fields[0].Type != "int" || fields[0].Doc != "initial count" {
+		t.Fatalf("@param comment with custom delimiters was not parsed, got fields %+v", fields)
+	}
+
+	component, err := c.GetComponent("counter", map[string]any{"start": 2})
+	if err != nil {
+		t.Fatalf("GetComponent() error = %v", err)
+	}
+
+	b := new(bytes.Buffer)
+	if err := component.Render(b); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	want := `<div x-data="{ count: 2 }"><span>{{ count }}</span></div>`
+	if got := string(bytes.TrimSpace(b.Bytes())); got != want {
+		t.Errorf("output did not match:\n\n\twant: %s\n\tgot: %s\n\n", want, got)
+	}
+}
+
+func TestComponents_GetComponent_text(t *testing.T) {
+	c := NewTextComponents()
+
+	if err := c.Parse(`{{- define "email/Greeting" }}
+{{- /* @param name string */}}
+{{- /* @param count? int = 1 */}}
+Hi {{ .name }}, you have {{ .count }} <new> messages
+{{- end }}
+{{- define "email/Footer" }}
+{{- /* @param name string */}}
+{{- component "email/Greeting" "name" .name "count" 2 }} & bye
+{{- end }}`, "Sample"); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	var used []string
+	component, err := c.GetComponentUses("emailfooter", map[string]any{"name": "<John & Jane>"}, func(name string) { used = append(used, name) })
+	if err != nil {
+		t.Fatalf("GetComponent() error = %v", err)
+	}
+
+	b := new(bytes.Buffer)
+	if err := component.Render(b); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	want := "\nHi <John & Jane>, you have 2 <new> messages & bye"
+	if got := b.String(); got != want {
+		t.Errorf("output did not match:\n\n\twant: %q\n\tgot: %q\n\n", want, got)
+	}
+
+	if strings.Join(used, ",") != "emailgreeting" {
+		t.Errorf("uses was not called with nested component, got %v", used)
+	}
+}
+
+func TestComponents_GetComponent_textCycles(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		attrs    map[string]any
+		maxDepth int
+		want     string
+		wantErr  string
+	}{
+		{
+			name: "1. component using itself, via another component, never ending",
+			input: `{{- define "Card" }}[{{ component "CardBody" }}]{{- end }}
+{{- define "CardBody" }}({{ component "Card" }}){{- end }}`,
+			wantErr: "components are nested deeper than (100), (Card → CardBody → Card → CardBody",
+		},
+		{
+			name: "2. components nested deeper than max depth",
+			input: `{{- define "Card" }}[{{ component "CardBody" }}]{{- end }}
+{{- define "CardBody" }}({{ component "Card" }}){{- end }}`,
+			maxDepth: 1,
+			wantErr:  "components are nested deeper than (1), (Card → CardBody)",
+		},
+		{
+			name:  "3. component using itself, until its data ends the recursion",
+			input: `{{- define "Card" }}{{ .n }}{{ if gt .n 0 }},{{ component "Card" "n" (sub .n 1) }}{{ end }}{{- end }}`,
+			attrs: map[string]any{"n": 3},
+			want:  "3,2,1,0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewTextComponents()
+			c.MaxDepth = tt.maxDepth
+			if err := c.Parse(tt.input, "Sample"); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			component, err := c.GetComponent("card", tt.attrs)
+			if err != nil {
+				t.Fatalf("GetComponent() error = %v", err)
+			}
+
+			b := new(bytes.Buffer)
+			err = component.Render(b)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Render() error = %v", err)
+				}
+
+				if b.String() != tt.want {
+					t.Errorf("Render() = %q, want %q", b.String(), tt.want)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Render() error did not match:\n\n\twant: %s\n\tgot: %v\n\n", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestComponents_GetComponent_textConcurrently(t *testing.T) {
+	c := NewTextComponents()
+	if err := c.Parse(`{{- define "Card" }}{{ .n }}{{ if gt .n 0 }},{{ component "Card" "n" (sub .n 1) }}{{ end }}{{- end }}`, "Sample"); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var used []string
+			component, err := c.GetComponentUses("card", map[string]any{"n": i}, func(name string) { used = append(used, name) })
+			if err != nil {
+				t.Errorf("GetComponentUses() error = %v", err)
+				return
+			}
+
+			if err := component.Render(io.Discard); err != nil {
+				t.Errorf("Render() error = %v", err)
+			}
+
+			if len(used) != i {
+				t.Errorf("uses was called %d times, for %d nested components", len(used), i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestComponents_GetComponent_namespaces(t *testing.T) {
+	c := NewComponents()
+
+	c.Namespace = "ui"
+	if err := c.Parse(`{{- define "Button" }}<button class="ui">{{.label}}</button>{{- end }}`, "Sample"); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	c.Namespace = "marketing"
+	if err := c.Parse(`{{- define "Button" }}<a class="marketing">{{.label}}</a>{{- end }}`, "Sample"); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"ui:Button":        `<button class="ui">Buy</button>`,
+		"marketing:button": `<a class="marketing">Buy</a>`,
+	} {
+		component, err := c.GetComponent(name, map[string]any{"label": "Buy"})
+		if err != nil {
+			t.Fatalf("GetComponent(%s) error = %v", name, err)
+		}
+
+		b := new(bytes.Buffer)
+		if err := component.Render(b); err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+
+		if got := b.String(); got != want {
+			t.Errorf("%s output did not match:\n\n\twant: %s\n\tgot: %s\n\n", name, want, got)
+		}
+	}
+
+	if _, err := c.GetComponent("button", nil); err == nil {
+		t.Errorf("GetComponent() expected error, for a namespaced component without its namespace")
+	}
+
+	var names []string
+	for _, s := range c.Structs() {
+		names = append(names, s.Name)
+	}
+	if want := "MarketingButton,UiButton"; strings.Join(names, ",") != want {
+		t.Errorf("Structs() names did not match:\n\n\twant: %s\n\tgot: %s\n\n", want, strings.Join(names, ","))
+	}
+
+	c.Namespace = "ui"
+	err := c.Parse(`{{- define "Button" }}<button class="other"></button>{{- end }}`, "Sample")
+	if want := `duplicate component (ui:Button), it is already defined in (define "Button")`; err == nil || err.Error() != want {
+		t.Errorf("Parse() error did not match:\n\n\twant: %s\n\tgot: %v\n\n", want, err)
+	}
+}
+
+func TestComponents_GetComponent_propErrors(t *testing.T) {
+	c := NewComponents()
+	if err := c.Parse(`{{- define "component/Input" }}
+{{- /* @param id? string */}}
+{{- /* @param label string */}}
+{{- /* @param type string */}}
+<input id="{{.id}}" type="{{.type}}" aria-label="{{.label}}" />
+{{- end }}`, "Sample"); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	_, err := c.GetComponent("componentinput", map[string]any{"id": "email"})
+	if err == nil {
+		t.Fatalf("GetComponent() expected error, got none")
+	}
+
+	var got []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var pe *html_parser.PropError
+		if !errors.As(e, &pe) {
+			t.Fatalf("expected error of type *PropError, got %T", e)
+		}
+		got = append(got, fmt.Sprintf("%s.%s:%s", pe.Component, pe.Field, pe.Rule))
+	}
+
+	want := []string{"ComponentInput.type:required", "ComponentInput.label:required"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("prop errors did not match:\n\n\twant: %v\n\tgot: %v\n\n", want, got)
+	}
+}
+
+func TestComponents_GetComponent_strict(t *testing.T) {
+	components := `{{- define "component/Input" }}
+{{- /* @param label string */}}
+{{- /* @param count? int */}}
+{{- /* @param disabled? bool */}}
+<label>{{.label}} ({{.count}}, {{.disabled}})</label>
+{{- end }}
+
+{{- define "component/Button" }}
+{{- /* @param label string */}}
+<button {{.props}}>{{.label}}</button>
+{{- end }}`
+
+	tests := []struct {
+		name      string
+		component string
+		attrs     map[string]any
+		want      []string
+	}{
+		{
+			name:      "1. known attributes, with valid literals",
+			component: "componentinput",
+			attrs:     map[string]any{"label": "Email", "count": "2", "disabled": ""},
+		},
+		{
+			name:      "2. misspelt attribute",
+			component: "componentinput",
+			attrs:     map[string]any{"label": "Email", "lable": "Email"},
+			want:      []string{"ComponentInput.lable:unknown"},
+		},
+		{
+			name:      "3. mis-typed literals",
+			component: "componentinput",
+			attrs:     map[string]any{"label": "Email", "count": "abc", "disabled": "maybe"},
+			want:      []string{"ComponentInput.count:type", "ComponentInput.disabled:type"},
+		},
+		{
+			name:      "4. unknown attributes, with component rendering props",
+			component: "componentbutton",
+			attrs:     map[string]any{"label": "Submit", "type": "submit"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewComponents()
+			c.Strict = true
+			if err := c.Parse(components, "Sample"); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			_, err := c.GetComponent(tt.component, tt.attrs)
+
+			var got []string
+			for _, e := range html_parser.FlattenErrors(err) {
+				var pe *html_parser.PropError
+				if !errors.As(e, &pe) {
+					t.Fatalf("expected error of type *PropError, got %T", e)
+				}
+				got = append(got, fmt.Sprintf("%s.%s:%s", pe.Component, pe.Field, pe.Rule))
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("prop errors did not match:\n\n\twant: %v\n\tgot: %v\n\n", tt.want, got)
+			}
+		})
+	}
+}
@@ -1,19 +1,33 @@
 package template
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"text/template"
+
+	"github.com/nxtcoder17/htmlc/pkg/funcs"
+	"github.com/nxtcoder17/htmlc/pkg/types"
 )
 
+const fileParserTemplateName = "t:parser"
+
 type FileParser struct {
 	t       *template.Template
 	Content string
 }
 
 func (fp *FileParser) Parse() (parsedTmpl string, imports []string, structs []Struct, err error) {
-	result := make([]Struct, 0, len(fp.t.Templates()))
-	for _, v := range fp.t.Templates() {
-		if v.Name() == "t:parser" {
+	templates := fp.t.Templates()
+
+	// INFO: templates are sorted by name, as Templates() order is random, and generated code should be stable
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name() < templates[j].Name() })
+
+	var errs []error
+
+	result := make([]Struct, 0, len(templates))
+	for _, v := range templates {
+		if v.Name() == fileParserTemplateName {
 			continue
 		}
 
@@ -25,10 +39,18 @@ func (fp *FileParser) Parse() (parsedTmpl string, imports []string, structs []St
 		}
 		s.FromTemplate = v.Name()
 
+		if err := typeCheck(v, s); err != nil {
+			errs = append(errs, err)
+		}
+
 		result = append(result, s)
 		imports = append(imports, s.Imports...)
 	}
 
+	if len(errs) > 0 {
+		return "", nil, nil, errors.Join(errs...)
+	}
+
 	imports = append(imports,
 		"github.com/go-playground/validator/v10",
 		"io",
@@ -38,31 +60,41 @@ func (fp *FileParser) Parse() (parsedTmpl string, imports []string, structs []St
 	return fp.Content, imports, result, nil
 }
 
-func NewFileParser(content string, defaultStructName string) (*FileParser, error) {
-	t := template.New("t:parser")
-	funcs := template.FuncMap{
-		paramLabel: func(key, value string) string {
-			return "/* comment */"
-		},
+// NewFileParser parses content, where go template actions use delims (if given), or the default `{{` and `}}`
+func NewFileParser(content string, defaultStructName string, delims ...types.Delims) (*FileParser, error) {
+	var d types.Delims
+	if len(delims) >= 1 {
+		d = delims[0]
+	}
+
+	t := template.New(fileParserTemplateName).Delims(d.Left, d.Right)
+	funcMap := template.FuncMap(funcs.FuncMap())
+	funcMap[paramLabel] = func(key, value string, defaultValueAndDoc ...string) string {
+		return "/* comment */"
+	}
+	// INFO: text components use other components, with `{{ component "Name" "key" .value }}`
+	funcMap["component"] = func(name string, attrs ...any) string {
+		return ""
 	}
 
-	t.Funcs(funcs)
+	t.Funcs(funcMap)
 
-	t, err := t.Parse(fixParamComments(content))
+	t, err := t.Parse(fixParamComments(content, d))
 	if err != nil {
 		return nil, err
 	}
 
 	if len(t.Templates()) == 1 {
-		content = fmt.Sprintf(`{{- define "%s"}}
+		dd := d.OrDefault()
+		content = fmt.Sprintf(`%s- define "%s"%s
 %s
 
-{{- end }}`, defaultStructName, content)
+%s- end %s`, dd.Left, defaultStructName, dd.Right, content, dd.Left, dd.Right)
 	}
 
-	t = template.New("t:parser")
-	t.Funcs(funcs)
-	t.Parse(fixParamComments(content))
+	t = template.New(fileParserTemplateName).Delims(d.Left, d.Right)
+	t.Funcs(funcMap)
+	t.Parse(fixParamComments(content, d))
 
 	return &FileParser{
 		t:       t,
@@ -5,21 +5,99 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"text/template"
 	"text/template/parse"
 
 	fn "github.com/nxtcoder17/htmlc/pkg/functions"
+	"github.com/nxtcoder17/htmlc/pkg/types"
 )
 
 const paramLabel string = "__param__"
 
 var defaultStructName = "YourStdoutStruct"
 
-func parseNode(p parse.Node, prefix string, onNodeFound func(sf StructField, isComment bool)) {
+// elemSegment is a path segment for an element of a slice, i.e. [Items, [], Name] is .Name inside {{ range .Items }}
+const elemSegment = "[]"
+
+// scope is what dot, and variables refer to, while walking a template, as paths from the template data.
+// A nil path is unknown, i.e. dot inside {{ range (index .A 0) }}
+type scope struct {
+	dot  []string
+	vars map[string][]string
+
+	// t is the template being walked, to look up templates included with {{ template "x" . }}
+	t *template.Template
+
+	// visiting guards against templates including themselves
+	visiting map[string]bool
+}
+
+func newScope(t *template.Template) *scope {
+	return &scope{
+		dot:      []string{},
+		vars:     map[string][]string{"$": {}},
+		t:        t,
+		visiting: make(map[string]bool),
+	}
+}
+
+// with returns a child scope, where dot is rebound to path
+func (sc *scope) with(dot []string) *scope {
+	vars := make(map[string][]string, len(sc.vars))
+	for k, v := range sc.vars {
+		vars[k] = v
+	}
+	return &scope{dot: dot, vars: vars, t: sc.t, visiting: sc.visiting}
+}
+
+func joinPath(base []string, idents ...string) []string {
+	if base == nil {
+		return nil
+	}
+	return append(slices.Clone(base), idents...)
+}
+
+// pipePath returns the path, a pipeline evaluates to, when it is a plain field access (i.e. .User, $user.Address or .),
+// and nil otherwise
+func pipePath(pipe *parse.PipeNode, sc *scope) []string {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return nil
+	}
+
+	return argPath(pipe.Cmds[0].Args[0], sc)
+}
+
+func argPath(arg parse.Node, sc *scope) []string {
+	switch node := arg.(type) {
+	case *parse.DotNode:
+		return sc.dot
+	case *parse.FieldNode:
+		return joinPath(sc.dot, node.Ident...)
+	case *parse.VariableNode:
+		return joinPath(sc.vars[node.Ident[0]], node.Ident[1:]...)
+	case *parse.ChainNode:
+		return joinPath(argPath(node.Node, sc), node.Field...)
+	case *parse.PipeNode:
+		return pipePath(node, sc)
+	}
+	return nil
+}
+
+// onPath reports the field, for a path from the template data
+func onPath(path []string, onNodeFound func(sf StructField, isComment bool)) {
+	if len(path) == 0 || path[0] == elemSegment {
+		return
+	}
+	onNodeFound(fieldFromPath(path), false)
+}
+
+func parseNode(p parse.Node, sc *scope, onNodeFound func(sf StructField, isComment bool)) {
 	if p == nil {
 		return
 	}
@@ -27,37 +105,49 @@ func parseNode(p parse.Node, prefix string, onNodeFound func(sf StructField, isC
 	switch node := p.(type) {
 	case *parse.IdentifierNode:
 		{
-			slog.Debug("identifier node", "prefix", prefix, "node", node.String())
+			slog.Debug("identifier node", "node", node.String())
 			// INFO: IdentifierNode is always a function like go-template builtin's like `eq`, `or`, `and`, `not` etc
 			// No need to parse it, any further
-			// onNodeFound(toStructField(node.String(), "any"), false)
 		}
 
-	// CASE: .Variable
-	case *parse.FieldNode:
+	// CASE: .Variable, $variable.Field, (.Variable).Field
+	case *parse.FieldNode, *parse.VariableNode, *parse.ChainNode:
 		{
-			for _, v := range node.Ident {
-				onNodeFound(toStructField(v, "any"), false)
+			slog.Debug("field node", "node", node.String())
+			// INFO: .User.Address.City is a single field User, with nested fields Address.City
+			onPath(argPath(node, sc), onNodeFound)
+
+			if chain, ok := node.(*parse.ChainNode); ok {
+				parseNode(chain.Node, sc, onNodeFound)
+			}
+		}
+
+	case *parse.PipeNode:
+		{
+			if node == nil {
+				return
+			}
+
+			for _, c := range node.Cmds {
+				parseNode(c, sc, onNodeFound)
+			}
+
+			// INFO: {{ $user := .User }}, makes $user.Name refer to .User.Name
+			if len(node.Decl) == 1 {
+				sc.vars[node.Decl[0].Ident[0]] = pipePath(&parse.PipeNode{Cmds: node.Cmds}, sc)
 			}
-			slog.Debug("field node", "prefix", prefix, "node", node.String())
 		}
 
 	// CASE: {{.Variable}}
 	case *parse.ActionNode:
 		{
-			slog.Debug("action node", "prefix", prefix, "node", node.Pipe.String())
+			slog.Debug("action node", "node", node.Pipe.String())
 
 			if node.Pipe == nil {
 				return
 			}
 
-			if node.Pipe.IsAssign {
-				slog.Debug("is being assigned", "node", node.Pipe.String())
-			}
-
 			for _, c := range node.Pipe.Cmds {
-				slog.Debug("got comment", "c.Args", c.Args)
-
 				if len(c.Args) >= 3 && c.Args[0].String() == paramLabel {
 					// INFO: it is our param comment
 					varName, err := strconv.Unquote(c.Args[1].String())
@@ -70,59 +160,115 @@ func parseNode(p parse.Node, prefix string, onNodeFound func(sf StructField, isC
 						continue
 					}
 
-					onNodeFound(toStructField(varName, varType), true)
-					continue
-				}
+					sf := toStructField(varName, varType)
+					if len(c.Args) >= 4 {
+						if sf.Default, err = strconv.Unquote(c.Args[3].String()); err != nil {
+							continue
+						}
+					}
+
+					if sf.Default != "" {
+						// INFO: a param with a default value, is never missing
+						sf.Required = false
+						sf.Tag = fmt.Sprint("`", fmt.Sprintf(`json:"%s"`, sf.JsonName), "`")
+					}
 
-				for idx, ci := range c.Args {
-					slog.Debug(fmt.Sprintf("cmds[%d]", idx), "type", ci.Type(), "cmd", ci.String())
-					parseNode(ci, fmt.Sprintf("cmds[%d]", idx), onNodeFound)
+					if len(c.Args) >= 5 {
+						if sf.Doc, err = strconv.Unquote(c.Args[4].String()); err != nil {
+							continue
+						}
+					}
+
+					onNodeFound(sf, true)
+					return
 				}
 			}
-			// for _, d := range node.Pipe.Decl {
-			// 	pp("decl", d.String())
-			// }
-			// *result = append(*result, node.Pipe.String())
-			// return
+
+			parseNode(node.Pipe, sc, onNodeFound)
 		}
 	case *parse.IfNode:
 		{
-			if node.Pipe == nil {
-				return
+			slog.Debug("if node", "node", node.String())
+
+			// INFO: variables declared in if pipeline are visible in both the bodies, and the ones declared in a body
+			// are only visible in that body, as in go templates
+			inner := sc.with(sc.dot)
+			parseNode(node.Pipe, inner, onNodeFound)
+			parseNode(node.List, inner.with(inner.dot), onNodeFound)
+			parseNode(node.ElseList, inner.with(inner.dot), onNodeFound)
+		}
+	case *parse.WithNode:
+		{
+			slog.Debug("with node", "node", node.String())
+
+			inner := sc.with(sc.dot)
+			parseNode(node.Pipe, inner, onNodeFound)
+
+			// INFO: dot is rebound to the pipeline value, inside with block, but not inside its else block
+			inner.dot = pipePath(node.Pipe, sc)
+			parseNode(node.List, inner, onNodeFound)
+			parseNode(node.ElseList, sc.with(sc.dot), onNodeFound)
+		}
+	case *parse.RangeNode:
+		{
+			slog.Debug("range-node", "node", node.String())
+
+			inner := sc.with(sc.dot)
+			for _, c := range node.Pipe.Cmds {
+				parseNode(c, inner, onNodeFound)
 			}
 
-			slog.Debug("if node", "prefix", prefix, "node", node.String(), "pipe", node.Pipe.Cmds)
+			// INFO: dot is rebound to each element, inside range block, so fields there are fields of the element
+			elem := joinPath(pipePath(&parse.PipeNode{Cmds: node.Pipe.Cmds}, sc), elemSegment)
+			inner.dot = elem
 
-			for i := range node.Pipe.Cmds {
-				parseNode(node.Pipe.Cmds[i], prefix, onNodeFound)
+			switch len(node.Pipe.Decl) {
+			case 1:
+				inner.vars[node.Pipe.Decl[0].Ident[0]] = elem
+			case 2:
+				inner.vars[node.Pipe.Decl[0].Ident[0]] = nil
+				inner.vars[node.Pipe.Decl[1].Ident[0]] = elem
 			}
 
-			parseNode(node.List, "if", onNodeFound)
-			parseNode(node.ElseList, "else", onNodeFound)
+			parseNode(node.List, inner, onNodeFound)
+			parseNode(node.ElseList, sc.with(sc.dot), onNodeFound)
+		}
+	case *parse.TemplateNode:
+		{
+			slog.Debug("template node", "node", node.String())
+
+			parseNode(node.Pipe, sc, onNodeFound)
+
+			// INFO: fields used in the included template, are fields of the data passed to it
+			included := sc.t.Lookup(node.Name)
+			dot := pipePath(node.Pipe, sc)
+			if included == nil || included.Tree == nil || dot == nil || sc.visiting[node.Name] {
+				return
+			}
+
+			sc.visiting[node.Name] = true
+			defer delete(sc.visiting, node.Name)
+
+			inner := sc.with(dot)
+			inner.vars = map[string][]string{"$": dot}
+			parseNode(included.Root, inner, onNodeFound)
 		}
 	case *parse.ListNode:
 		if node == nil {
 			return
 		}
-		slog.Debug("list node", "prefix", prefix, "node", node)
 		for i := range node.Nodes {
-			parseNode(node.Nodes[i], fmt.Sprintf("list [%d]", i), onNodeFound)
+			parseNode(node.Nodes[i], sc, onNodeFound)
 		}
 
 	case *parse.CommandNode:
 		slog.Debug("command-node", "node", node.String())
 		for i := range node.Args {
-			parseNode(node.Args[i], fmt.Sprintf("list [%d]", i), onNodeFound)
-		}
-	case *parse.RangeNode:
-		if node.Pipe == nil {
-			return
+			parseNode(node.Args[i], sc, onNodeFound)
 		}
 
-		slog.Debug("range-node", "node", node.String(), "struct", fmt.Sprintf("%+v", *node.Pipe))
-		for i := range node.Pipe.Cmds {
-			parseNode(node.Pipe.Cmds[i], "range-node", onNodeFound)
-		}
+	case *parse.BreakNode, *parse.ContinueNode, *parse.TextNode, *parse.CommentNode, *parse.DotNode:
+		// INFO: nothing to extract
 	}
 }
 
@@ -131,6 +277,7 @@ func structFromTemplate(structName string, t *template.Template) (Struct, error)
 	commentsMap := make(map[string]StructField)
 
 	fieldsMap := make(map[string]int)
+	usesProps := false
 
 	onVarFound := func(sf StructField, isFromComment bool) {
 		if isFromComment {
@@ -141,65 +288,162 @@ func structFromTemplate(structName string, t *template.Template) (Struct, error)
 		}
 
 		if sf.Name == "Props" || sf.Name == "Remaining" {
+			usesProps = true
 			return
 		}
 
-		if _, ok := fieldsMap[sf.Name]; !ok {
-			fields = append(fields, sf)
-			fieldsMap[sf.Name] = len(fields) - 1
+		if idx, ok := fieldsMap[sf.Name]; ok {
+			fields[idx].Fields = mergeFields(fields[idx].Fields, sf.Fields)
+			fields[idx].Slice = fields[idx].Slice || sf.Slice
+			return
 		}
-	}
 
-	for _, n := range t.Root.Nodes {
-		parseNode(n, "", onVarFound)
+		fields = append(fields, sf)
+		fieldsMap[sf.Name] = len(fields) - 1
 	}
 
+	sc := newScope(t)
+	sc.visiting[t.Name()] = true
+	parseNode(t.Root, sc, onVarFound)
+
 	var imports []string
 
 	for i := range fields {
 		if sf, ok := commentsMap[fields[i].Name]; ok {
-			if idx := strings.LastIndex(sf.Type, "."); idx != -1 {
-				pkg := sf.Type[:idx]
-				imports = append(imports, pkg)
-				fields[i].Package = &pkg
-				fields[i].Type = fmt.Sprintf("%s.%s", filepath.Base(pkg), sf.Type[idx+1:])
-				fields[i].Tag = sf.Tag
-				continue
-			}
 			fields[i].Type = sf.Type
 			fields[i].Tag = sf.Tag
+			fields[i].Required = sf.Required
+			fields[i].Default = sf.Default
+			fields[i].Doc = sf.Doc
+
+			if isEnumType(sf.Type) {
+				enum, err := parseEnum(structName+fields[i].Name, sf.Type)
+				if err != nil {
+					return Struct{}, fmt.Errorf("%s: @param %s: %w", structName, fields[i].JsonName, err)
+				}
+				fields[i].Type = structName + fields[i].Name
+				fields[i].Enum = enum
+				imports = append(imports, "errors")
+			} else {
+				typ, pkgs, err := parseTypeExpr(sf.Type)
+				if err != nil {
+					return Struct{}, fmt.Errorf("%s: @param %s: %w", structName, fields[i].JsonName, err)
+				}
+				fields[i].Type = typ
+				imports = append(imports, pkgs...)
+
+				if len(pkgs) == 1 {
+					fields[i].Package = &pkgs[0]
+				}
+			}
+		}
+
+		if _, ok := commentsMap[fields[i].Name]; ok {
+			// INFO: a dotted path on a `@param` field, is tied to its declared type
+			fields[i].Fields = nil
+		} else if len(fields[i].Fields) > 0 {
+			fields[i].Type = inferredType(fields[i])
+		}
+
+		if _, err := fields[i].DefaultValue(); err != nil {
+			return Struct{}, fmt.Errorf("%s: @param %s: %w", structName, fields[i].JsonName, err)
 		}
 	}
 
 	return Struct{
-		Name:    structName,
-		Fields:  fields,
-		Imports: imports,
+		Name:      structName,
+		Fields:    fields,
+		Imports:   imports,
+		UsesProps: usesProps,
 	}, nil
 }
 
-var re = func() *regexp.Regexp {
+var re = paramCommentRe(types.Delims{})
+
+// paramCommentRe returns the regexp, for `@param` comments in templates, using delims
+func paramCommentRe(delims types.Delims) *regexp.Regexp {
+	d := delims.OrDefault()
+
 	// old := regexp.MustCompile(`{{-?\s*/\*\s* @param \s*(\w*) \s*((\w|\[\]|_)+).*\*/}}`)
 	return regexp.MustCompile(
 		// comment start
-		`{{-?\s*[/][*]\s*` +
+		regexp.QuoteMeta(d.Left) + `-?\s*[/][*]\s*` +
 			// param keyword
 			" @param " +
-			// var name
-			`\s*(\w+)` +
-			// var type
-			// 		\w: could be a alphanumeric character
-			// 		\[,\]: could be an array type
-			// 		[*]: could be a pointer type
-			`\s*((\w|\[\]|[*])+)` +
+			// var name, with an optional `?` suffix for optional params
+			`\s*(\w+[?]?)` +
+			// var type, default value and description, split by [splitParamSpec]
+			`\s+(.*?)` +
 
 			// comment end
-			`.*[*][/].*}}`,
+			`\s*[*][/]\s*-?` + regexp.QuoteMeta(d.Right),
 	)
-}()
+}
+
+var (
+	// enumTypeRe matches an enum type, i.e. "a"|"b", a closed set of string values
+	enumTypeRe = regexp.MustCompile(`^"[^"]*"(?:\s*[|]\s*"[^"]*")*`)
 
-func fixParamComments(tmpl string) string {
-	result := re.ReplaceAllString(tmpl, fmt.Sprintf(`{{- %s "$1" "$2" -}}`, paramLabel))
+	// paramDefaultRe matches a default value, an optional go literal, i.e. = "md", = 10, = true
+	paramDefaultRe = regexp.MustCompile(`^\s*=\s*("(?:[^"\\]|\\.)*"|[\w.+-]+)`)
+
+	// paramDocRe matches a description, an optional text after `--`, i.e. -- visible label text
+	paramDocRe = regexp.MustCompile(`^\s+--\s*(.*)$`)
+)
+
+// splitParamSpec splits what follows the param name in a `@param` comment, into its type, default value and description.
+// Type is either an enum, or the longest prefix that is a go type expression (i.e. string, []*models.User, func() string),
+// any other text after them is ignored, i.e. `string the visible label` is a string
+func splitParamSpec(spec string) (typ string, defaultValue string, doc string) {
+	var rest string
+	if m := enumTypeRe.FindString(spec); m != "" {
+		typ, rest = m, spec[len(m):]
+	} else {
+		// INFO: without any valid prefix, whole spec is the type, so that it is reported as an invalid type
+		typ, rest = spec, ""
+		for i := len(spec); i > 0; i-- {
+			if i < len(spec) && !strings.ContainsRune(" \t=", rune(spec[i])) {
+				continue
+			}
+
+			if _, _, err := parseTypeExpr(strings.TrimSpace(spec[:i])); err == nil {
+				typ, rest = strings.TrimSpace(spec[:i]), spec[i:]
+				break
+			}
+		}
+	}
+
+	if m := paramDefaultRe.FindStringSubmatch(rest); m != nil {
+		defaultValue, rest = m[1], rest[len(m[0]):]
+	}
+
+	if m := paramDocRe.FindStringSubmatch(rest); m != nil {
+		doc = m[1]
+	}
+
+	return typ, defaultValue, doc
+}
+
+// fixParamComments replaces `@param` comments in tmpl (with delims), with calls to paramLabel func, so that they are a part of parsed template
+func fixParamComments(tmpl string, delims types.Delims) string {
+	d := delims.OrDefault()
+
+	re := re
+	if !delims.IsDefault() {
+		re = paramCommentRe(delims)
+	}
+
+	result := re.ReplaceAllStringFunc(tmpl, func(comment string) string {
+		m := re.FindStringSubmatch(comment)
+		typ, defaultValue, doc := splitParamSpec(m[2])
+		switch {
+		case doc != "":
+			return fmt.Sprintf(`%s- %s %q %q %q %q -%s`, d.Left, paramLabel, m[1], typ, defaultValue, doc, d.Right)
+		case defaultValue != "":
+			return fmt.Sprintf(`%s- %s %q %q %q -%s`, d.Left, paramLabel, m[1], typ, defaultValue, d.Right)
+		}
+		return fmt.Sprintf(`%s- %s %q %q -%s`, d.Left, paramLabel, m[1], typ, d.Right)
+	})
 	slog.Debug("POST PARAM REPLACEMENT", "component", result)
 	return result
 }
@@ -217,6 +461,10 @@ var ParsedPkgInitFileTemplate string
 type Parser struct {
 	templateImport string
 
+	// defined maps a struct (by its output dir, and name), to the file it is generated from, so that structs of
+	// different input dirs, generated into the same package, do not collide
+	defined map[string]string
+
 	parsedStructFileTemplate  *template.Template
 	parsedPkgInitFileTemplate *template.Template
 }
@@ -226,6 +474,19 @@ type ParseOptions struct {
 	StructNamePrefix        *string
 	PreProcess              func(tmpl string) (string, error)
 	GeneratingForComponents bool
+
+	// Route (for pages) is the URL path, the page is served at, by generated `Routes()` handler
+	Route *string
+
+	// Delims are the go template delimiters, templates use, empty ones are the default `{{` and `}}`
+	Delims types.Delims
+
+	// Namespace (for components) is the namespace, components are registered in, i.e. ui for <ui:Button>
+	Namespace string
+
+	// Schema makes ParseDir write JSON Schema of every struct, and a typescript definitions file (<outputPkg>.d.ts)
+	// with all of them, into output dir
+	Schema bool
 }
 
 func (p *Parser) ParseDir(inputDir string, outputDir string, outputPkg string, opts ...ParseOptions) error {
@@ -256,76 +517,190 @@ func (p *Parser) ParseDir(inputDir string, outputDir string, outputPkg string, o
 		return err
 	}
 
+	var structs []Struct
 	for _, item := range listings {
 		slog.Debug("template-parser | listings", "item", item)
-
-		input, err := os.ReadFile(filepath.Join(inputDir, item))
+		s, err := p.parseFile(inputDir, item, outputDir, outputPkg, opt)
 		if err != nil {
 			return err
 		}
 
-		base := filepath.Base(item)
-		base = toFieldName(base[:len(base)-len(filepath.Ext(base))])
-
-		defStructName := base
-		if opt.StructNamePrefix != nil {
-			defStructName = toFieldName(*opt.StructNamePrefix + base)
+		// INFO: components with the same name, would replace each other in generated Components map
+		file := filepath.Join(inputDir, item)
+		for _, st := range s {
+			key := filepath.Join(outputDir, st.Name)
+			if other, ok := p.defined[key]; ok && other != file {
+				return fmt.Errorf("%s: duplicate component (%s), it is already defined in (%s)", file, st.Name, other)
+			}
+			p.defined[key] = file
 		}
+		structs = append(structs, s...)
+	}
 
-		parseFuncName := "parse" + defStructName
+	if opt.Schema {
+		return WriteSchemas(outputDir, outputPkg, structs)
+	}
 
-		outFile := filepath.Join(outputDir, fmt.Sprintf("%s_generated.go", item))
-		if err := os.MkdirAll(filepath.Dir(outFile), 0o766); err != nil {
-			return err
-		}
+	return nil
+}
 
-		if err := p.parse(string(input), defStructName, parseFuncName, &outFile, outputPkg, opt); err != nil {
-			return err
-		}
+// PageRoute returns the URL path, a page is served at, i.e. users/register.html at /users/register,
+// and users/index.html at /users/
+func PageRoute(entry string) string {
+	route := path.Clean("/" + filepath.ToSlash(entry))
+	route = strings.TrimSuffix(route, path.Ext(route))
+
+	if path.Base(route) == "index" {
+		return strings.TrimSuffix(route, "index")
 	}
 
-	return nil
+	return route
+}
+
+// ParseFile parses a single template file (item), relative to inputDir, and writes its generated go file into outputDir
+func (p *Parser) ParseFile(inputDir string, item string, outputDir string, outputPkg string, opts ...ParseOptions) error {
+	opt := ParseOptions{}
+
+	if len(opts) >= 1 {
+		opt = opts[0]
+	}
+
+	_, err := p.parseFile(inputDir, item, outputDir, outputPkg, opt)
+	return err
+}
+
+// parseFile does ParseFile, and returns the structs generated from item
+func (p *Parser) parseFile(inputDir string, item string, outputDir string, outputPkg string, opt ParseOptions) ([]Struct, error) {
+	input, err := os.ReadFile(filepath.Join(inputDir, item))
+	if err != nil {
+		return nil, err
+	}
+
+	base := filepath.Base(item)
+	base = toFieldName(base[:len(base)-len(filepath.Ext(base))])
+
+	defStructName := base
+	if opt.StructNamePrefix != nil {
+		defStructName = toFieldName(*opt.StructNamePrefix + base)
+	}
+
+	parseFuncName := "parse" + defStructName
+
+	outFile := filepath.Join(outputDir, fmt.Sprintf("%s_generated.go", item))
+	if opt.Namespace != "" {
+		// INFO: components of different namespaces, could be defined in files with the same name
+		parseFuncName = "parse" + generateStructName(opt.Namespace) + defStructName
+		outFile = filepath.Join(outputDir, filepath.Dir(item), fmt.Sprintf("%s_%s_generated.go", opt.Namespace, filepath.Base(item)))
+	}
+	if err := os.MkdirAll(filepath.Dir(outFile), 0o766); err != nil {
+		return nil, err
+	}
+
+	return p.parse(string(input), defStructName, parseFuncName, &outFile, outputPkg, opt)
 }
 
 func (p *Parser) Parse(input string, outputFile *string, outputPkg string, opts ParseOptions) error {
 	parseFuncName := "parseStdout"
-	return p.parse(input, defaultStructName, parseFuncName, outputFile, outputPkg, opts)
+	_, err := p.parse(input, defaultStructName, parseFuncName, outputFile, outputPkg, opts)
+	return err
 }
 
-func (p *Parser) parse(input string, structName string, parseFuncName string, outputFile *string, outputPkg string, opts ParseOptions) error {
-	fp, err := NewFileParser(string(input), structName)
+func (p *Parser) parse(input string, structName string, parseFuncName string, outputFile *string, outputPkg string, opts ParseOptions) ([]Struct, error) {
+	if opts.Namespace != "" {
+		// INFO: components of all the namespaces are parsed into the same Template, so their templates are prefixed
+		// with the namespace, i.e. ui:Button, and so are their structs, i.e. UiButton
+		input = namespaceTemplates(input, opts.Delims, opts.Namespace)
+		structName = opts.Namespace + ":" + structName
+	}
+
+	fp, err := NewFileParser(string(input), structName, opts.Delims)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	tmpl, imports, structs, err := fp.Parse()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if opts.GeneratingForComponents {
+		for i := range structs {
+			name := generateStructName(strings.TrimPrefix(structs[i].FromTemplate, opts.Namespace+":"))
+			structs[i].Key = componentKey(opts.Namespace, name)
+		}
 	}
 
 	// INFO: to remove @param comments, in generated file
 	// tmpl = removeParamComments(tmpl)
 
-	imports = append(imports, p.templateImport)
+	// INFO: template package is only used for props, as template.HTMLAttr
+	if p.templateImport == "html/template" {
+		imports = append(imports, p.templateImport)
+	}
+	slices.Sort(imports)
+	imports = slices.Compact(imports)
+
+	var route, routeStruct string
+	if opts.Route != nil {
+		route = *opts.Route
+		if routeStruct, err = pageStruct(structs, structName); err != nil {
+			return nil, err
+		}
+	}
+
 	out := os.Stdout
 
 	if outputFile != nil {
-		out, err = os.Create(*outputFile)
+		f, err := os.Create(*outputFile)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		defer f.Close()
+		out = f
 	}
 
-	return p.PrintParsedStructFile(out, printOutputArgs{
+	return structs, p.PrintParsedStructFile(out, printOutputArgs{
 		Package:                 outputPkg,
 		Imports:                 imports,
 		Structs:                 structs,
 		ParseFuncName:           parseFuncName,
 		InputTemplate:           tmpl,
+		TemplateImport:          p.templateImport,
+		Delims:                  opts.Delims,
 		GeneratingForComponents: opts.GeneratingForComponents,
+		Namespace:               opts.Namespace,
+		Route:                   route,
+		RouteStruct:             routeStruct,
+	})
+}
+
+// namespaceTemplates prefixes names of the templates, tmpl defines or includes, with namespace, i.e. {{ define "ui:Button" }}.
+// An included template is of the same namespace, as components of a namespace are parsed into their own template
+func namespaceTemplates(tmpl string, delims types.Delims, namespace string) string {
+	d := delims.OrDefault()
+	re := regexp.MustCompile(regexp.QuoteMeta(d.Left) + `(-?\s*(?:define|template|block)\s+)"([^"]*)"`)
+
+	return re.ReplaceAllStringFunc(tmpl, func(action string) string {
+		m := re.FindStringSubmatch(action)
+		return fmt.Sprintf(`%s%s"%s:%s"`, d.Left, m[1], namespace, m[2])
 	})
 }
 
+// pageStruct returns the struct, that renders the page
+func pageStruct(structs []Struct, structName string) (string, error) {
+	if len(structs) == 1 {
+		return structs[0].Name, nil
+	}
+
+	for _, s := range structs {
+		if s.Name == structName {
+			return s.Name, nil
+		}
+	}
+
+	return "", fmt.Errorf("page has multiple templates, and none of them is named (%s), to be served", structName)
+}
+
 type TemplateType string
 
 const (
@@ -357,6 +732,10 @@ func NewParser(ttype TemplateType) (*Parser, error) {
 		"lowercase": func(str string) string {
 			return strings.ToLower(str)
 		},
+
+		"join": func(items []string, sep string) string {
+			return strings.Join(items, sep)
+		},
 	}
 
 	t, err := template.New("parse").Funcs(funcs).Parse(ParsedStructOutputTemplate)
@@ -370,6 +749,7 @@ func NewParser(ttype TemplateType) (*Parser, error) {
 	}
 
 	return &Parser{
+		defined:                   make(map[string]string),
 		templateImport:            fmt.Sprintf("%s/template", ttype),
 		parsedStructFileTemplate:  t,
 		parsedPkgInitFileTemplate: outputPkgTmpl,
@@ -203,6 +203,219 @@ Greeting: {{.Greeting}}
 			},
 			wantErr: false,
 		},
+		{
+			name: "8. dotted field access, infers a nested struct",
+			args: args{
+				tmpl: /*gotmpl*/ `
+{{ define "Sample" }}
+Hello, {{ .User.Name }}, from {{ .User.Address.City }}
+{{- if .User.Address.Zip }}({{ .User.Address.Zip }}){{ end }}
+{{- end }}
+`,
+			},
+			want: []Struct{
+				{
+					Name: "Sample",
+					Fields: []StructField{
+						{Name: "User", Type: "struct {\nName any `json:\"Name\"`\nAddress struct {\nCity any `json:\"City\"`\nZip any `json:\"Zip\"`\n} `json:\"Address\"`\n}"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "9. dotted field access, on a declared param",
+			args: args{
+				tmpl: /*gotmpl*/ `
+{{ define "Sample" }}
+{{- /* @param user *net/url.Userinfo */}}
+Hello, {{ .user.Username }}
+{{- end }}
+`,
+			},
+			want: []Struct{
+				{
+					Name:    "Sample",
+					Imports: []string{"net/url"},
+					Fields: []StructField{
+						{Name: "User", Type: "*url.Userinfo"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "10. range body, fields are of the element type",
+			args: args{
+				tmpl: /*gotmpl*/ `
+{{ define "Sample" }}
+{{- range .Items }}
+{{- if .Done }}{{ break }}{{ end }}
+{{- if not .Title }}{{ continue }}{{ end }}
+<li>{{ .Title }} by {{ $.Owner }}</li>
+{{- else }}
+<li>{{ .Empty }}</li>
+{{- end }}
+{{- end }}
+`,
+			},
+			want: []Struct{
+				{
+					Name: "Sample",
+					Fields: []StructField{
+						{Name: "Items", Type: "[]struct {\nDone any `json:\"Done\"`\nTitle any `json:\"Title\"`\n}"},
+						{Name: "Owner", Type: "any"},
+						{Name: "Empty", Type: "any"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "11. range with variables",
+			args: args{
+				tmpl: /*gotmpl*/ `
+{{ define "Sample" }}
+{{- range $i, $item := .Items }}
+<li>{{ $i }}: {{ $item.Title }}</li>
+{{- end }}
+{{- $user := .User }}
+{{ $user.Name }}
+{{- end }}
+`,
+			},
+			want: []Struct{
+				{
+					Name: "Sample",
+					Fields: []StructField{
+						{Name: "Items", Type: "[]struct {\nTitle any `json:\"Title\"`\n}"},
+						{Name: "User", Type: "struct {\nName any `json:\"Name\"`\n}"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "12. with body, and template includes",
+			args: args{
+				tmpl: /*gotmpl*/ `
+{{ define "Sample" }}
+{{- with .User }}
+  {{ .Name }}
+  {{ template "address" .Address }}
+{{- else }}
+  {{ .Guest }}
+{{- end }}
+{{- end }}
+
+{{ define "address" }}{{ .City }}{{ end }}
+`,
+			},
+			want: []Struct{
+				{
+					Name: "Sample",
+					Fields: []StructField{
+						{Name: "User", Type: "struct {\nName any `json:\"Name\"`\nAddress struct {\nCity any `json:\"City\"`\n} `json:\"Address\"`\n}"},
+						{Name: "Guest", Type: "any"},
+					},
+				},
+				{
+					Name: "Address",
+					Fields: []StructField{
+						{Name: "City", Type: "any"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "13. range, and with over fields, not used in their bodies",
+			args: args{
+				tmpl: /*gotmpl*/ `
+{{- /* @param Tags []string */}}
+{{- range .Tags }}{{ . }}{{ end }}
+{{- with .User }}{{ . }}{{ end }}
+`,
+			},
+			want: []Struct{
+				{
+					Name: defaultStructName,
+					Fields: []StructField{
+						{Name: "Tags", Type: "[]string"},
+						{Name: "User", Type: "any"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "14. params with descriptions",
+			args: args{
+				tmpl: /*gotmpl*/ `
+{{- /* @param label string -- visible label text */}}
+{{- /* @param size? "sm"|"md" = "md" -- size of the label, i.e. "sm" */}}
+{{- /* @param count? int = 10 */}}
+<label class="{{.size}}">{{.label}} {{.count}}</label>
+`,
+			},
+			want: []Struct{
+				{
+					Name:    defaultStructName,
+					Imports: []string{"errors"},
+					Fields: []StructField{
+						{Name: "Size", Type: defaultStructName + "Size", Doc: `size of the label, i.e. "sm"`},
+						{Name: "Label", Type: "string", Doc: "visible label text"},
+						{Name: "Count", Type: "int"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "15. params with text after their types, without `--`, is ignored",
+			args: args{
+				tmpl: /*gotmpl*/ `
+{{- /* @param label string the visible label */}}
+{{- /* @param render func() string renders the icon */}}
+<label>{{.label}} {{.render}}</label>
+`,
+			},
+			want: []Struct{
+				{
+					Name: defaultStructName,
+					Fields: []StructField{
+						{Name: "Label", Type: "string"},
+						{Name: "Render", Type: "func() string"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "16. variables declared in if, and else bodies, are not visible after them",
+			args: args{
+				tmpl: /*gotmpl*/ `
+{{ define "Sample" }}
+{{- $x := .User }}
+{{- if .Admin }}{{ $x := .Admin }}{{ $x.Role }}{{ else }}{{ $x := .Guest }}{{ $x.Id }}{{ end }}
+{{- with .Team }}{{ .Lead }}{{ else }}{{ $x := .Guest }}{{ end }}
+{{ $x.Name }}
+{{- end }}
+`,
+			},
+			want: []Struct{
+				{
+					Name: "Sample",
+					Fields: []StructField{
+						{Name: "User", Type: "struct {\nName any `json:\"Name\"`\n}"},
+						{Name: "Admin", Type: "struct {\nRole any `json:\"Role\"`\n}"},
+						{Name: "Guest", Type: "struct {\nId any `json:\"Id\"`\n}"},
+						{Name: "Team", Type: "struct {\nLead any `json:\"Lead\"`\n}"},
+					},
+				},
+			},
+			wantErr: false,
+		},
 	}
 	for _idx, tt := range tests {
 		idx := _idx + 1
@@ -280,3 +493,72 @@ Greeting: {{.Greeting}}
 		})
 	}
 }
+
+func Test_splitParamSpec(t *testing.T) {
+	tests := []struct {
+		spec             string
+		wantType         string
+		wantDefaultValue string
+		wantDoc          string
+	}{
+		{spec: `string`, wantType: "string"},
+		{spec: `string the visible label`, wantType: "string"},
+		{spec: `map[string]int -- counts`, wantType: "map[string]int", wantDoc: "counts"},
+		{spec: `func(a int) string`, wantType: "func(a int) string"},
+		{spec: `string = "md" size of the label`, wantType: "string", wantDefaultValue: `"md"`},
+		{spec: `int=10 -- the count`, wantType: "int", wantDefaultValue: "10", wantDoc: "the count"},
+		{spec: `"sm"|"md" = "md" -- size`, wantType: `"sm"|"md"`, wantDefaultValue: `"md"`, wantDoc: "size"},
+		{spec: `[]] the items`, wantType: "[]] the items"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			typ, defaultValue, doc := splitParamSpec(tt.spec)
+			if typ != tt.wantType || defaultValue != tt.wantDefaultValue || doc != tt.wantDoc {
+				t.Errorf("splitParamSpec(%s) = (%q, %q, %q), want (%q, %q, %q)", tt.spec, typ, defaultValue, doc, tt.wantType, tt.wantDefaultValue, tt.wantDoc)
+			}
+		})
+	}
+}
+
+func Test_pageStruct(t *testing.T) {
+	tests := []struct {
+		name       string
+		structs    []Struct
+		structName string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "1. page with a single template",
+			structs:    []Struct{{Name: "PageRegister"}},
+			structName: "PageRegister",
+			want:       "PageRegister",
+		},
+		{
+			name:       "2. page with multiple templates, one of them named after the page",
+			structs:    []Struct{{Name: "Header"}, {Name: "PageRegister"}},
+			structName: "PageRegister",
+			want:       "PageRegister",
+		},
+		{
+			name:       "3. page with multiple templates, none named after the page",
+			structs:    []Struct{{Name: "Header"}, {Name: "Footer"}},
+			structName: "PageRegister",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pageStruct(tt.structs, tt.structName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pageStruct() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("pageStruct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -18,24 +18,56 @@ package {{ .Package }}
 func init() {
   {{- if .GeneratingForComponents }}
   {{- range $structs }}
-  Components[{{.Name | lowercase | quote}}] = func(attr map[string]any) (Component, error) {
+  Components[{{ .LookupKey | quote }}] = func(attr map[string]any) (Component, error) {
     return New{{.Name}}(attr)
   }
 
   {{- end }}
   {{- end }}
+
+  {{- if .Route }}
+  routes[{{.Route | quote}}] = func(attrs map[string]any) (Page, error) {
+    return New{{.RouteStruct}}(attrs)
+  }
+  {{- end }}
   
   {{.ParseFuncName}}()
 }
 
 func {{.ParseFuncName}}() error {
-  _, err := Template.Parse(`{{.InputTemplate}}`)
+  // INFO: delimiters are set for every file, as Template keeps the ones of the file parsed before it, empty ones are the defaults
+  _, err := Template.Delims({{ .Delims.Left | quote }}, {{ .Delims.Right | quote }}).Parse(`{{.InputTemplate}}`)
   return err
 }
 
 {{- range $structs }}
+
+{{- range $v := .Fields }}
+{{- if $v.Enum }}
+type {{ $v.Type }} string
+
+const (
+  {{- range $v.Enum }}
+  {{ .Name }} {{ $v.Type }} = {{ .Value | quote }}
+  {{- end }}
+)
+
+// Validate returns an error, if v is not one of the allowed values
+func (v {{ $v.Type }}) Validate() error {
+  switch v {
+  case {{ range $i, $e := $v.Enum }}{{ if $i }}, {{ end }}{{ $e.Name }}{{ end }}:
+    return nil
+  }
+  return fmt.Errorf("expected one of (%s), got %q", {{ join $v.EnumValues ", " | quote }}, string(v))
+}
+{{- end }}
+{{- end }}
+
 type {{.Name}} struct {
   {{- range $v := .Fields }}
+  {{- if $v.Doc }}
+  // {{ $v.Doc }}
+  {{- end }}
   {{ $v.Name }} {{ $v.Type }} {{$v.Tag}}
   {{- end }}
 
@@ -48,21 +80,40 @@ type {{.Name}} struct {
 func New{{.Name}}(attrs map[string]any) (*{{.Name}}, error) {
 	var s {{.Name}}
 
+  {{- if .HasDefaults }}
+
+  // INFO: default values (from @param comments), for the missing attributes
+  withDefaults := map[string]any{
+    {{- range $v := .Fields }}
+    {{- if $v.Default }}
+    {{ $v.JsonName | quote }}: {{ $v.Default }},
+    {{- end }}
+    {{- end }}
+  }
+  for k, v := range attrs {
+    withDefaults[k] = v
+  }
+  attrs = withDefaults
+  {{- end }}
+
   decoderCfg := &mapstructure.DecoderConfig{
     WeaklyTypedInput: true,
     Result:           &s,
     TagName:          "json",
   }
 
-	decoder, _ := mapstructure.NewDecoder(decoderCfg)
+	decoder, err := mapstructure.NewDecoder(decoderCfg)
+  if err != nil {
+    return nil, err
+  }
+
   if err := decoder.Decode(attrs); err != nil {
-    panic(err)
+    return nil, &PropError{Component: {{.Name | quote}}, Rule: "decode", Err: err}
   }
 
-  validate := validator.New()
-  if err := validate.Struct(s); err != nil {
-    fmt.Println("Validation failed:", err)
-  } 
+  if err := s.Validate(); err != nil {
+    return nil, err
+  }
 
 	known := map[string]any{
     {{- range $v := .Fields }}
@@ -86,7 +137,11 @@ func New{{.Name}}(attrs map[string]any) (*{{.Name}}, error) {
   _ = strings.ToLower
 
 
+  {{- if eq $.TemplateImport "html/template" }}
   s.raw["props"] = template.HTMLAttr(strings.Join(unknown, " "))
+  {{- else }}
+  s.raw["props"] = strings.Join(unknown, " ")
+  {{- end }}
 
 	return &s, nil
 }
@@ -95,9 +150,26 @@ func (n *{{.Name}}) TemplateName() string {
   return {{.FromTemplate | quote}}
 }
 
+// Validate returns validation failures as PropError(s)
 func (n *{{.Name}}) Validate() error {
   validate := validator.New(validator.WithRequiredStructEnabled())
-  return validate.Struct(n)
+  validate.RegisterTagNameFunc(jsonFieldName)
+  {{- if .HasEnums }}
+  {{- $name := .Name }}
+  errs := []error{toPropErrors({{.Name | quote}}, validate.Struct(n))}
+  {{- range $v := .Fields }}
+  {{- if $v.Enum }}
+  if n.{{ $v.Name }} != "" {
+    if err := n.{{ $v.Name }}.Validate(); err != nil {
+      errs = append(errs, &PropError{Component: {{ $name | quote }}, Field: {{ $v.JsonName | quote }}, Rule: "oneof", Err: err})
+    }
+  }
+  {{- end }}
+  {{- end }}
+  return errors.Join(errs...)
+  {{- else }}
+  return toPropErrors({{.Name | quote}}, validate.Struct(n))
+  {{- end }}
 }
 
 func (n *{{.Name}}) Render(w io.Writer) error {
@@ -1,13 +1,112 @@
 package {{ .Package }}
 
 import (
-  {{.TemplateImport | quote}}
-  {{- if .GeneratingForComponents }}
+  "errors"
+  "fmt"
   "io"
+  "reflect"
+  "strings"
+  {{.TemplateImport | quote}}
+  {{- if not .GeneratingForComponents }}
+  "bytes"
+  "net/http"
   {{- end }}
+
+  "github.com/go-playground/validator/v10"
 )
 
-var Template *template.Template = template.New("template:{{.Package}}")
+{{- if and .GeneratingForComponents (eq .TemplateImport "text/template") }}
+// renderComponentFn is renderComponent, it is assigned in init(), as Template's initialization could not refer to it
+var renderComponentFn func(name string, kv ...any) (string, error)
+
+// INFO: FuncMap is defined in funcs_generated.go, text components use other components with `{{"{{"}} component "Name" "key" .value {{"}}"}}`
+var Template *template.Template = template.New("template:{{.Package}}").Funcs(FuncMap()).Funcs(template.FuncMap{
+  "component": func(name string, kv ...any) (string, error) {
+    return renderComponentFn(name, kv...)
+  },
+})
+
+func init() {
+  renderComponentFn = renderComponent
+}
+
+// renderComponent renders component name, with attributes as key, value pairs
+func renderComponent(name string, kv ...any) (string, error) {
+  if len(kv)%2 != 0 {
+    return "", fmt.Errorf("component (%s): attributes must be key, value pairs", name)
+  }
+
+  attrs := make(map[string]any, len(kv)/2)
+  for i := 0; i < len(kv); i += 2 {
+    k, ok := kv[i].(string)
+    if !ok {
+      return "", fmt.Errorf("component (%s): attribute name must be a string, got %T", name, kv[i])
+    }
+    attrs[k] = kv[i+1]
+  }
+
+  newComponent, ok := Components[strings.ToLower(strings.NewReplacer("/", "", "-", "", ".", "").Replace(name))]
+  if !ok {
+    return "", fmt.Errorf("unknown component (%s)", name)
+  }
+
+  c, err := newComponent(attrs)
+  if err != nil {
+    return "", err
+  }
+
+  b := new(strings.Builder)
+  if err := c.Render(b); err != nil {
+    return "", err
+  }
+  return b.String(), nil
+}
+{{- else }}
+// INFO: FuncMap is defined in funcs_generated.go
+var Template *template.Template = template.New("template:{{.Package}}").Funcs(FuncMap())
+{{- end }}
+
+// PropError is returned, when a component (or page) is given an invalid attribute
+type PropError struct {
+  Component string
+  Field     string
+  Rule      string
+  Err       error
+}
+
+func (e *PropError) Error() string {
+  msg := fmt.Sprintf("%s: attribute (%s) failed on (%s)", e.Component, e.Field, e.Rule)
+  if e.Err != nil {
+    msg += fmt.Sprintf(": %s", e.Err)
+  }
+  return msg
+}
+
+func (e *PropError) Unwrap() error {
+  return e.Err
+}
+
+// jsonFieldName makes validator report fields by their attribute name
+func jsonFieldName(f reflect.StructField) string {
+  name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+  if name == "-" {
+    return ""
+  }
+  return name
+}
+
+func toPropErrors(component string, err error) error {
+  var verrs validator.ValidationErrors
+  if !errors.As(err, &verrs) {
+    return err
+  }
+
+  errs := make([]error, 0, len(verrs))
+  for _, fe := range verrs {
+    errs = append(errs, &PropError{Component: component, Field: fe.Field(), Rule: fe.Tag()})
+  }
+  return errors.Join(errs...)
+}
 
 {{- if .GeneratingForComponents }}
 type GetComponentFn func(attr map[string]any) (Component, error)
@@ -16,5 +115,83 @@ var Components map[string]GetComponentFn = make(map[string]GetComponentFn)
 type Component interface {
   Render(w io.Writer) error
 }
+{{- else }}
+
+type Page interface {
+  Render(w io.Writer) error
+}
+
+type NewPageFn func(attrs map[string]any) (Page, error)
+
+// routes maps a URL path, to the page served at it
+var routes map[string]NewPageFn = make(map[string]NewPageFn)
+
+// Loader returns the data (attributes), for the page being served at route
+type Loader func(r *http.Request, route string) (map[string]any, error)
+
+// Routes returns an http.Handler, that serves every page at its route, i.e. pages/users/register.html at /users/register,
+// and pages/users/index.html at /users/, a route requested with (or without) a trailing slash is redirected to
+//
+// load (could be nil) supplies the data, for the page being served
+func Routes(load Loader) http.Handler {
+  return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    newPage, ok := routes[r.URL.Path]
+    if !ok {
+      // INFO: a route requested with (or without) a trailing slash is redirected to, i.e. /register/ to /register,
+      // as http.ServeMux does
+      other := strings.TrimSuffix(r.URL.Path, "/")
+      if other == r.URL.Path {
+        other += "/"
+      }
+
+      if _, found := routes[other]; found {
+        u := *r.URL
+        u.Path = other
+        http.Redirect(w, r, u.String(), http.StatusMovedPermanently)
+        return
+      }
+
+      http.NotFound(w, r)
+      return
+    }
+
+    if r.Method != http.MethodGet && r.Method != http.MethodHead {
+      w.Header().Set("Allow", "GET, HEAD")
+      http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+      return
+    }
+
+    var attrs map[string]any
+    if load != nil {
+      var err error
+      if attrs, err = load(r, r.URL.Path); err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+      }
+    }
+
+    page, err := newPage(attrs)
+    if err != nil {
+      // INFO: attributes are loaded for the request, so the invalid ones are a bad request
+      status := http.StatusInternalServerError
+      var pe *PropError
+      if errors.As(err, &pe) {
+        status = http.StatusBadRequest
+      }
+      http.Error(w, err.Error(), status)
+      return
+    }
+
+    // INFO: rendering into a buffer, so that a failing render does not write a partial page
+    b := new(bytes.Buffer)
+    if err := page.Render(b); err != nil {
+      http.Error(w, err.Error(), http.StatusInternalServerError)
+      return
+    }
+
+    w.Header().Set("Content-Type", {{ if eq .TemplateImport "html/template" }}"text/html; charset=utf-8"{{ else }}"text/plain; charset=utf-8"{{ end }})
+    w.Write(b.Bytes())
+  })
+}
 {{- end }}
 
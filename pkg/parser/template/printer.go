@@ -8,6 +8,9 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+
+	"github.com/nxtcoder17/htmlc/pkg/funcs"
+	"github.com/nxtcoder17/htmlc/pkg/types"
 )
 
 type printOutputArgs struct {
@@ -16,7 +19,18 @@ type printOutputArgs struct {
 	Structs                 []Struct
 	ParseFuncName           string
 	InputTemplate           string
+	TemplateImport          string
 	GeneratingForComponents bool
+
+	// Namespace is the namespace of components, i.e. ui for <ui:Button>, they are registered as ui:button
+	Namespace string
+
+	// Delims are the delimiters, InputTemplate uses
+	Delims types.Delims
+
+	// Route is the URL path, the page is served at, with RouteStruct
+	Route       string
+	RouteStruct string
 }
 
 func (p *Parser) PrintParsedStructFile(writer io.Writer, args printOutputArgs) error {
@@ -43,10 +57,15 @@ type PrintPkgInitFileArgs struct {
 }
 
 func (p *Parser) PrintPkgInitFile(args PrintPkgInitFileArgs) error {
+	if err := os.WriteFile(filepath.Join(args.Dir, "funcs_generated.go"), []byte(funcs.Source(args.Package)), 0o644); err != nil {
+		return err
+	}
+
 	w, err := os.Create(filepath.Join(args.Dir, "init.go"))
 	if err != nil {
 		return err
 	}
+	defer w.Close()
 
 	b := new(bytes.Buffer)
 	if err := p.parsedPkgInitFileTemplate.Execute(b, map[string]any{
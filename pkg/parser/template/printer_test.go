@@ -0,0 +1,325 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nxtcoder17/htmlc/pkg/types"
+)
+
+// generatedGoMod is the go.mod, for compiling a generated package, with the dependencies of generated code
+const generatedGoMod = `module example.com/generated
+
+go 1.23.0
+
+require (
+	github.com/go-playground/validator/v10 v10.24.0
+	github.com/mitchellh/mapstructure v1.5.0
+)
+`
+
+// testGeneratedPackage compiles, vets and tests the generated package in dir, as a module of its own. It never
+// downloads any module, and is skipped when dependencies of generated code are not in the module cache
+func testGeneratedPackage(t *testing.T, dir string) {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("skipping compiling generated package, in short mode")
+	}
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain is not available")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(generatedGoMod), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	goCmd := func(args ...string) ([]byte, error) {
+		cmd := exec.Command(goBin, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off", "GOPROXY=off", "GOSUMDB=off")
+		return cmd.CombinedOutput()
+	}
+
+	if out, err := goCmd("list", "-deps", "-test", "./..."); err != nil {
+		t.Skipf("dependencies of generated package could not be resolved, without downloading them: %v\n%s", err, out)
+	}
+
+	for _, args := range [][]string{{"vet", "./..."}, {"test", "./..."}} {
+		if out, err := goCmd(args...); err != nil {
+			t.Fatalf("go %s, failed on generated package: %v\n%s", args[0], err, out)
+		}
+	}
+}
+
+func TestPageRoute(t *testing.T) {
+	tests := []struct {
+		entry string
+		want  string
+	}{
+		{entry: "index.html", want: "/"},
+		{entry: "register.html", want: "/register"},
+		{entry: "users/register.html", want: "/users/register"},
+		{entry: "users/index.html", want: "/users/"},
+		{entry: "users/index/about.txt", want: "/users/index/about"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			if got := PageRoute(tt.entry); got != tt.want {
+				t.Errorf("PageRoute(%s) = %s, want %s", tt.entry, got, tt.want)
+			}
+		})
+	}
+}
+
+// routesTest is written into the generated pages package, to test its Routes() handler
+const routesTest = `package pages
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	h := Routes(func(r *http.Request, route string) (map[string]any, error) {
+		if r.URL.Query().Has("invalid") {
+			return map[string]any{}, nil
+		}
+		return map[string]any{"name": "gopher"}, nil
+	})
+
+	tests := []struct {
+		method       string
+		target       string
+		wantCode     int
+		wantBody     string
+		wantLocation string
+	}{
+		{method: http.MethodGet, target: "/", wantCode: http.StatusOK, wantBody: "<p>hi gopher</p>"},
+		{method: http.MethodGet, target: "/register", wantCode: http.StatusOK, wantBody: "<form>register</form>"},
+		{method: http.MethodGet, target: "/register/?next=1", wantCode: http.StatusMovedPermanently, wantLocation: "/register?next=1"},
+		{method: http.MethodGet, target: "/index", wantCode: http.StatusNotFound},
+		{method: http.MethodGet, target: "/unknown/", wantCode: http.StatusNotFound},
+		{method: http.MethodGet, target: "/unknown", wantCode: http.StatusNotFound},
+		{method: http.MethodPost, target: "/", wantCode: http.StatusMethodNotAllowed},
+		{method: http.MethodGet, target: "/?invalid", wantCode: http.StatusBadRequest, wantBody: "attribute (name) failed on (required)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.wantCode, w.Body)
+			}
+
+			if location := w.Header().Get("Location"); location != tt.wantLocation {
+				t.Errorf("location = %s, want %s", location, tt.wantLocation)
+			}
+
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", w.Body, tt.wantBody)
+			}
+
+			if tt.wantCode == http.StatusOK {
+				if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+					t.Errorf("content type = %s, want text/html", ct)
+				}
+			}
+		})
+	}
+}
+`
+
+func TestRoutes(t *testing.T) {
+	dir := t.TempDir()
+
+	pages := map[string]string{
+		"index.html": `{{- /* @param name string */}}
+<p>hi {{.name}}</p>`,
+		"register.html": `<form>register</form>`,
+	}
+
+	p, err := NewParser(Html)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.PrintPkgInitFile(PrintPkgInitFileArgs{Dir: dir, Package: "pages"}); err != nil {
+		t.Fatal(err)
+	}
+
+	prefix := "page"
+	for entry, content := range pages {
+		if err := os.WriteFile(filepath.Join(dir, entry), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		route := PageRoute(entry)
+		if err := p.ParseFile(dir, entry, dir, "pages", ParseOptions{StructNamePrefix: &prefix, Route: &route}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "routes_test.go"), []byte(routesTest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	testGeneratedPackage(t, dir)
+}
+
+// delimsTest is written into the generated components package, to test components using different delimiters
+const delimsTest = `package components
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDelims(t *testing.T) {
+	for name, want := range map[string]string{"custom": "<b>custom</b>", "plain": "<i>plain</i>"} {
+		c, err := Components[name](map[string]any{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b := new(strings.Builder)
+		if err := c.Render(b); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := strings.TrimSpace(b.String()); got != want {
+			t.Errorf("%s rendered %q, want %q", name, got, want)
+		}
+	}
+}
+`
+
+func TestParseFile_delims(t *testing.T) {
+	dir := t.TempDir()
+
+	// INFO: Custom is parsed first, as generated files are initialized in the order of their names
+	files := []struct {
+		name    string
+		content string
+		delims  types.Delims
+	}{
+		{name: "Custom.html", content: `[[ define "Custom" ]]<b>[[ "custom" ]]</b>[[ end ]]`, delims: types.Delims{Left: "[[", Right: "]]"}},
+		{name: "Plain.html", content: `{{ define "Plain" }}<i>{{ "plain" }}</i>{{ end }}`},
+	}
+
+	p, err := NewParser(Html)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.PrintPkgInitFile(PrintPkgInitFileArgs{Dir: dir, Package: "components", GeneratingForComponents: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, f.name), []byte(f.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := p.ParseFile(dir, f.name, dir, "components", ParseOptions{GeneratingForComponents: true, Delims: f.delims}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "delims_test.go"), []byte(delimsTest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	testGeneratedPackage(t, dir)
+}
+
+// namespacesTest is written into the generated components package, to test components of different namespaces
+const namespacesTest = `package components
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNamespaces(t *testing.T) {
+	for key, want := range map[string]string{"ui:button": "<button class=\"ui a\">ui label</button>", "mk:button": "<button class=\"mk a\">mk label</button>"} {
+		c, err := Components[key](map[string]any{"variant": "a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b := new(strings.Builder)
+		if err := c.Render(b); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := strings.TrimSpace(b.String()); got != want {
+			t.Errorf("%s rendered %q, want %q", key, got, want)
+		}
+	}
+
+	var _ *UiButton = &UiButton{Variant: UiButtonVariantA}
+	var _ *MkButton = &MkButton{Variant: MkButtonVariantA}
+}
+`
+
+func TestParseDir_namespaces(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "components")
+
+	for _, ns := range []string{"ui", "mk"} {
+		if err := os.MkdirAll(filepath.Join(dir, ns), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		button := fmt.Sprintf(`{{- define "Button" }}
+{{- /* @param variant "a"|"b" */}}
+<button class="%s {{ .variant }}">{{ template "Label" . }}</button>
+{{- end }}
+{{- define "Label" }}%s label{{ end }}`, ns, ns)
+
+		if err := os.WriteFile(filepath.Join(dir, ns, "Button.html"), []byte(button), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := NewParser(Html)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ns := range []string{"ui", "mk"} {
+		if err := p.ParseDir(filepath.Join(dir, ns), output, "components", ParseOptions{GeneratingForComponents: true, Namespace: ns}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("duplicate components of different dirs, in the same namespace", func(t *testing.T) {
+		output := filepath.Join(dir, "duplicate")
+		if err := p.ParseDir(filepath.Join(dir, "ui"), output, "components", ParseOptions{GeneratingForComponents: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		err := p.ParseDir(filepath.Join(dir, "mk"), output, "components", ParseOptions{GeneratingForComponents: true})
+		if err == nil || !strings.Contains(err.Error(), "duplicate component (Button)") {
+			t.Fatalf("expected duplicate component error, got %v", err)
+		}
+	})
+
+	if err := os.WriteFile(filepath.Join(output, "namespaces_test.go"), []byte(namespacesTest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	testGeneratedPackage(t, output)
+}
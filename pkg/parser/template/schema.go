@@ -0,0 +1,317 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonSchema is a (draft 2020-12) JSON Schema, of a struct's attributes
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Default              any                    `json:"default,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+}
+
+// JSONSchema returns JSON Schema of the struct's attributes, optional params are not required,
+// and unknown attributes are allowed only when template renders `{{.props}}`
+func (st Struct) JSONSchema() ([]byte, error) {
+	s := &jsonSchema{
+		Schema:               "https://json-schema.org/draft/2020-12/schema",
+		Title:                st.Name,
+		Type:                 "object",
+		Properties:           make(map[string]*jsonSchema, len(st.Fields)),
+		AdditionalProperties: st.UsesProps,
+	}
+
+	for _, f := range st.Fields {
+		fs, err := fieldSchema(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: field (%s): %w", st.Name, f.JsonName, err)
+		}
+
+		s.Properties[f.JsonName] = fs
+		if f.Required {
+			s.Required = append(s.Required, f.JsonName)
+		}
+	}
+
+	return json.MarshalIndent(s, "", "  ")
+}
+
+func fieldSchema(f StructField) (*jsonSchema, error) {
+	if len(f.Enum) > 0 {
+		s := &jsonSchema{Type: "string", Enum: f.EnumValues(), Description: f.Doc}
+		s.Default, _ = f.DefaultValue()
+		return s, nil
+	}
+
+	expr, err := parser.ParseExpr(f.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	s := typeSchema(expr)
+	s.Description = f.Doc
+	if s.Default, err = f.DefaultValue(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// typeSchema maps a go type to JSON Schema, types that could not be described (i.e. any, func types) accept any value
+func typeSchema(expr ast.Expr) *jsonSchema {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		switch kind := basicKind(e.Name); kind {
+		case "":
+			return &jsonSchema{}
+		default:
+			return &jsonSchema{Type: kind}
+		}
+	case *ast.StarExpr:
+		return typeSchema(e.X)
+	case *ast.ArrayType:
+		return &jsonSchema{Type: "array", Items: typeSchema(e.Elt)}
+	case *ast.MapType:
+		return &jsonSchema{Type: "object", AdditionalProperties: typeSchema(e.Value)}
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok && x.Name == "time" {
+			switch e.Sel.Name {
+			case "Time":
+				return &jsonSchema{Type: "string", Format: "date-time"}
+			case "Duration":
+				return &jsonSchema{Type: "integer"}
+			}
+		}
+	case *ast.StructType:
+		s := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema)}
+		for _, f := range structFields(e) {
+			s.Properties[f.name] = typeSchema(f.typ)
+		}
+		return s
+	}
+
+	return &jsonSchema{}
+}
+
+// basicKind returns JSON Schema type, of a go builtin type, and an empty string for any other type
+func basicKind(name string) string {
+	switch name {
+	case "string":
+		return "string"
+	case "bool":
+		return "boolean"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte", "rune":
+		return "integer"
+	case "float32", "float64":
+		return "number"
+	}
+	return ""
+}
+
+type structTypeField struct {
+	name string
+	typ  ast.Expr
+}
+
+// structFields returns fields of an (anonymous) struct type, named by their json tags
+func structFields(st *ast.StructType) []structTypeField {
+	var result []structTypeField
+	for _, f := range st.Fields.List {
+		var tag string
+		if f.Tag != nil {
+			if v, err := strconv.Unquote(f.Tag.Value); err == nil {
+				tag, _, _ = strings.Cut(reflect.StructTag(v).Get("json"), ",")
+			}
+		}
+
+		if tag == "-" {
+			continue
+		}
+
+		for _, name := range f.Names {
+			if !name.IsExported() {
+				continue
+			}
+			if tag != "" {
+				result = append(result, structTypeField{name: tag, typ: f.Type})
+				continue
+			}
+			result = append(result, structTypeField{name: name.Name, typ: f.Type})
+		}
+	}
+	return result
+}
+
+// TypeScriptDefinitions returns a .d.ts, with an interface of attributes (props) for every struct, i.e. ComponentInputProps,
+// and a Components interface, that maps component names (as they are looked up, i.e. ui:button) to their props
+func TypeScriptDefinitions(structs []Struct) (string, error) {
+	structs = append([]Struct(nil), structs...)
+	sort.Slice(structs, func(i, j int) bool { return structs[i].Name < structs[j].Name })
+
+	sb := new(strings.Builder)
+	for _, st := range structs {
+		fmt.Fprintf(sb, "export interface %sProps {\n", st.Name)
+		for _, f := range st.Fields {
+			typ, err := fieldTSType(f)
+			if err != nil {
+				return "", fmt.Errorf("%s: field (%s): %w", st.Name, f.JsonName, err)
+			}
+
+			var docs []string
+			if f.Doc != "" {
+				docs = append(docs, f.Doc)
+			}
+			if f.Default != "" {
+				docs = append(docs, "@default "+f.Default)
+			}
+			if len(docs) > 0 {
+				fmt.Fprintf(sb, "  /** %s */\n", strings.Join(docs, " "))
+			}
+
+			optional := ""
+			if !f.Required {
+				optional = "?"
+			}
+			fmt.Fprintf(sb, "  %s%s: %s;\n", tsPropertyName(f.JsonName), optional, typ)
+		}
+		if st.UsesProps {
+			sb.WriteString("  [attr: string]: unknown;\n")
+		}
+		sb.WriteString("}\n\n")
+	}
+
+	sb.WriteString("export interface Components {\n")
+	for _, st := range structs {
+		fmt.Fprintf(sb, "  %s: %sProps;\n", tsPropertyName(st.LookupKey()), st.Name)
+	}
+	sb.WriteString("}\n")
+
+	return sb.String(), nil
+}
+
+func fieldTSType(f StructField) (string, error) {
+	if len(f.Enum) > 0 {
+		values := make([]string, 0, len(f.Enum))
+		for _, v := range f.EnumValues() {
+			values = append(values, strconv.Quote(v))
+		}
+		return strings.Join(values, " | "), nil
+	}
+
+	expr, err := parser.ParseExpr(f.Type)
+	if err != nil {
+		return "", err
+	}
+	return tsType(expr), nil
+}
+
+// tsType maps a go type to a typescript type, types that could not be described (i.e. any, func types) are unknown
+func tsType(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		switch basicKind(e.Name) {
+		case "string":
+			return "string"
+		case "boolean":
+			return "boolean"
+		case "integer", "number":
+			return "number"
+		}
+	case *ast.StarExpr:
+		return tsType(e.X)
+	case *ast.ArrayType:
+		elem := tsType(e.Elt)
+		if strings.Contains(elem, " ") {
+			return fmt.Sprintf("Array<%s>", elem)
+		}
+		return elem + "[]"
+	case *ast.MapType:
+		return fmt.Sprintf("Record<string, %s>", tsType(e.Value))
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok && x.Name == "time" {
+			switch e.Sel.Name {
+			case "Time":
+				return "string"
+			case "Duration":
+				return "number"
+			}
+		}
+	case *ast.StructType:
+		fields := structFields(e)
+		items := make([]string, 0, len(fields))
+		for _, f := range fields {
+			items = append(items, fmt.Sprintf("%s?: %s", tsPropertyName(f.name), tsType(f.typ)))
+		}
+		return fmt.Sprintf("{ %s }", strings.Join(items, "; "))
+	}
+
+	return "unknown"
+}
+
+var tsIdentifierRe = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func tsPropertyName(name string) string {
+	if tsIdentifierRe.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// WriteSchemas writes JSON Schema of every struct (<struct>.schema.json), and a typescript definitions file
+// (<dtsName>.d.ts) for all of them, into dir
+func WriteSchemas(dir string, dtsName string, structs []Struct) error {
+	if err := os.MkdirAll(dir, 0o766); err != nil {
+		return err
+	}
+
+	// INFO: components of different registries (i.e. html, and text ones) could have the same names
+	keys := make(map[string]string, len(structs))
+	names := make(map[string]string, len(structs))
+	for _, st := range structs {
+		if other, ok := keys[st.LookupKey()]; ok {
+			return fmt.Errorf("components (%s) and (%s), are both looked up as (%s)", other, st.Name, st.LookupKey())
+		}
+		keys[st.LookupKey()] = st.Name
+
+		if other, ok := names[st.Name]; ok {
+			return fmt.Errorf("components (%s) and (%s), have the same schema file (%s.schema.json)", other, st.LookupKey(), st.Name)
+		}
+		names[st.Name] = st.LookupKey()
+	}
+
+	for _, st := range structs {
+		b, err := st.JSONSchema()
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, st.Name+".schema.json"), append(b, '\n'), 0o644); err != nil {
+			return err
+		}
+	}
+
+	dts, err := TypeScriptDefinitions(structs)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, dtsName+".d.ts"), []byte(dts), 0o644)
+}
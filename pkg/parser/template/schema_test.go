@@ -0,0 +1,175 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func structsFromTemplate(t *testing.T, tmpl string) []Struct {
+	t.Helper()
+
+	fp, err := NewFileParser(tmpl, "Sample")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, structs, err := fp.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return structs
+}
+
+func TestStruct_JSONSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{
+			name: "1. required, optional, and default params",
+			tmpl: /*gotmpl*/ `
+{{- /* @param label string -- visible label text */}}
+{{- /* @param count? int = 10 */}}
+{{ .label }} {{ .count }}
+`,
+			want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","title":"Sample","type":"object","properties":{"count":{"type":"integer","default":10},"label":{"description":"visible label text","type":"string"}},"additionalProperties":false,"required":["label"]}`,
+		},
+		{
+			name: "2. enum, and types from go",
+			tmpl: /*gotmpl*/ `
+{{- /* @param variant? "primary"|"secondary" = "primary" */}}
+{{- /* @param tags []string */}}
+{{- /* @param scores map[string]float64 */}}
+{{- /* @param at *time.Time */}}
+{{- /* @param user *net/url.Userinfo */}}
+{{ .variant }} {{ .tags }} {{ .scores }} {{ .at }} {{ .user }}
+`,
+			want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","title":"Sample","type":"object","properties":{"at":{"type":"string","format":"date-time"},"scores":{"type":"object","additionalProperties":{"type":"number"}},"tags":{"type":"array","items":{"type":"string"}},"user":{},"variant":{"type":"string","enum":["primary","secondary"],"default":"primary"}},"additionalProperties":false,"required":["tags","scores","at","user"]}`,
+		},
+		{
+			name: "3. inferred nested struct, and props",
+			tmpl: /*gotmpl*/ `
+<p {{ .props }}>{{ .User.Name }}</p>
+`,
+			want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","title":"Sample","type":"object","properties":{"User":{"type":"object","properties":{"Name":{}}}},"additionalProperties":true,"required":["User"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			structs := structsFromTemplate(t, tt.tmpl)
+
+			got, err := structs[0].JSONSchema()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			compact := new(bytes.Buffer)
+			if err := json.Compact(compact, got); err != nil {
+				t.Fatal(err)
+			}
+
+			if compact.String() != tt.want {
+				t.Errorf("JSONSchema()\n\tgot:  %s\n\twant: %s", compact.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTypeScriptDefinitions(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{
+			name: "1. component props",
+			tmpl: /*gotmpl*/ `
+{{- define "component/Input" }}
+{{- /* @param label string -- visible label text */}}
+{{- /* @param size? "sm"|"md" = "md" */}}
+{{- /* @param items []map[string]int */}}
+<input {{ .props }} /> {{ .label }} {{ .size }} {{ .items }}
+{{- end }}
+`,
+			want: `export interface ComponentInputProps {
+  /** visible label text */
+  label: string;
+  /** @default "md" */
+  size?: "sm" | "md";
+  items: Array<Record<string, number>>;
+  [attr: string]: unknown;
+}
+
+export interface Components {
+  componentinput: ComponentInputProps;
+}
+`,
+		},
+		{
+			name: "2. inferred types",
+			tmpl: /*gotmpl*/ `
+{{- range .Items }}{{ .Title }}{{ end }}{{ .Any }}
+`,
+			want: `export interface SampleProps {
+  Items: Array<{ Title?: unknown }>;
+  Any: unknown;
+}
+
+export interface Components {
+  sample: SampleProps;
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TypeScriptDefinitions(structsFromTemplate(t, tt.tmpl))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("TypeScriptDefinitions()\n\tgot:\n%s\n\twant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypeScriptDefinitions_namespaces(t *testing.T) {
+	c := NewComponents()
+	c.Namespace = "ui"
+	if err := c.Parse(`{{- define "Button" }}<button>{{ .label }}</button>{{- end }}`, "Sample"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := TypeScriptDefinitions(c.Structs())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `  "ui:button": UiButtonProps;`; !strings.Contains(got, want) {
+		t.Errorf("TypeScriptDefinitions() does not have %s, got:\n%s", want, got)
+	}
+}
+
+func TestWriteSchemas_duplicates(t *testing.T) {
+	components := NewComponents()
+	if err := components.Parse(`{{- define "Button" }}<button></button>{{- end }}`, "Sample"); err != nil {
+		t.Fatal(err)
+	}
+
+	textComponents := NewTextComponents()
+	if err := textComponents.Parse(`{{- define "Button" }}button{{- end }}`, "Sample"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := WriteSchemas(t.TempDir(), "components", append(components.Structs(), textComponents.Structs()...))
+	if want := "components (Button) and (Button), are both looked up as (button)"; err == nil || err.Error() != want {
+		t.Errorf("WriteSchemas() error did not match:\n\n\twant: %s\n\tgot: %v\n\n", want, err)
+	}
+}
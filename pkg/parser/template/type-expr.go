@@ -0,0 +1,170 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// qualifiedIdentRe matches a type qualified by its full import path, i.e. github.com/acme/app/models.User
+var qualifiedIdentRe = regexp.MustCompile(`([\w.~-]+(?:/[\w.~-]+)+)\.([A-Za-z_]\w*)`)
+
+// packageName guesses the package name, from its import path, i.e. yaml for gopkg.in/yaml.v3
+func packageName(importPath string) string {
+	name := path.Base(importPath)
+	name, _, _ = strings.Cut(name, ".")
+	return strings.NewReplacer("-", "_", "~", "_").Replace(name)
+}
+
+// parseTypeExpr parses a go type expression, as written in a `@param` comment, i.e. map[string]int, []*models.User,
+// func() string, or []*github.com/acme/app/models.User.
+//
+// It returns the type, as it should be written in go code, and import paths of all the packages it refers to
+func parseTypeExpr(typ string) (string, []string, error) {
+	// INFO: package name, to its import path
+	packages := make(map[string]string)
+
+	var conflict error
+	expr := qualifiedIdentRe.ReplaceAllStringFunc(typ, func(s string) string {
+		m := qualifiedIdentRe.FindStringSubmatch(s)
+		name := packageName(m[1])
+		if other, ok := packages[name]; ok && other != m[1] {
+			conflict = fmt.Errorf("packages (%s) and (%s), have the same name (%s)", other, m[1], name)
+		}
+		packages[name] = m[1]
+		return name + "." + m[2]
+	})
+
+	if conflict != nil {
+		return "", nil, conflict
+	}
+
+	node, err := parser.ParseExpr(expr)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid type (%s): %w", typ, err)
+	}
+
+	if err := checkTypeExpr(node); err != nil {
+		return "", nil, fmt.Errorf("invalid type (%s): %w", typ, err)
+	}
+
+	imports := make(map[string]struct{})
+	ast.Inspect(node, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		if pkg, ok := sel.X.(*ast.Ident); ok {
+			importPath, ok := packages[pkg.Name]
+			if !ok {
+				// INFO: a type like time.Time, is from a standard library package
+				importPath = pkg.Name
+			}
+			imports[importPath] = struct{}{}
+		}
+		return false
+	})
+
+	b := new(bytes.Buffer)
+	if err := printer.Fprint(b, token.NewFileSet(), node); err != nil {
+		return "", nil, err
+	}
+
+	result := make([]string, 0, len(imports))
+	for k := range imports {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+
+	return b.String(), result, nil
+}
+
+// checkTypeExpr reports an error, if n is not a type expression
+func checkTypeExpr(n ast.Expr) error {
+	switch t := n.(type) {
+	case *ast.Ident:
+		return nil
+	case *ast.SelectorExpr:
+		if _, ok := t.X.(*ast.Ident); !ok {
+			return fmt.Errorf("unexpected (%s), expected a package qualified type", exprString(t))
+		}
+		return nil
+	case *ast.ParenExpr:
+		return checkTypeExpr(t.X)
+	case *ast.StarExpr:
+		return checkTypeExpr(t.X)
+	case *ast.ArrayType:
+		if t.Len != nil {
+			if _, ok := t.Len.(*ast.BasicLit); !ok {
+				if _, ok := t.Len.(*ast.Ellipsis); !ok {
+					return fmt.Errorf("array length must be a literal")
+				}
+			}
+		}
+		return checkTypeExpr(t.Elt)
+	case *ast.MapType:
+		if err := checkTypeExpr(t.Key); err != nil {
+			return err
+		}
+		return checkTypeExpr(t.Value)
+	case *ast.ChanType:
+		return checkTypeExpr(t.Value)
+	case *ast.Ellipsis:
+		return checkTypeExpr(t.Elt)
+	case *ast.FuncType:
+		if err := checkFieldList(t.Params); err != nil {
+			return err
+		}
+		return checkFieldList(t.Results)
+	case *ast.StructType:
+		return checkFieldList(t.Fields)
+	case *ast.InterfaceType:
+		return nil
+	case *ast.IndexExpr:
+		// INFO: generic type, with a single type argument
+		if err := checkTypeExpr(t.X); err != nil {
+			return err
+		}
+		return checkTypeExpr(t.Index)
+	case *ast.IndexListExpr:
+		if err := checkTypeExpr(t.X); err != nil {
+			return err
+		}
+		for _, idx := range t.Indices {
+			if err := checkTypeExpr(idx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unexpected (%s), expected a type", exprString(n))
+}
+
+func checkFieldList(fl *ast.FieldList) error {
+	if fl == nil {
+		return nil
+	}
+
+	for _, f := range fl.List {
+		if err := checkTypeExpr(f.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exprString prints expression n, for error messages
+func exprString(n ast.Node) string {
+	b := new(bytes.Buffer)
+	printer.Fprint(b, token.NewFileSet(), n)
+	return b.String()
+}
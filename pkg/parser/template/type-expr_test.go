@@ -0,0 +1,90 @@
+package template
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseTypeExpr(t *testing.T) {
+	tests := []struct {
+		name        string
+		typ         string
+		wantType    string
+		wantImports []string
+		wantErr     bool
+	}{
+		{
+			name:     "1. builtin type",
+			typ:      "string",
+			wantType: "string",
+		},
+		{
+			name:     "2. map type",
+			typ:      "map[string]int",
+			wantType: "map[string]int",
+		},
+		{
+			name:        "3. standard library type",
+			typ:         "*time.Time",
+			wantType:    "*time.Time",
+			wantImports: []string{"time"},
+		},
+		{
+			name:        "4. slice of pointers, to a type qualified by its import path",
+			typ:         "[]*github.com/acme/app/models.User",
+			wantType:    "[]*models.User",
+			wantImports: []string{"github.com/acme/app/models"},
+		},
+		{
+			name:        "5. map with types from multiple packages",
+			typ:         "map[github.com/acme/app/models.ID][]time.Duration",
+			wantType:    "map[models.ID][]time.Duration",
+			wantImports: []string{"github.com/acme/app/models", "time"},
+		},
+		{
+			name:     "6. func type",
+			typ:      "func(string, ...int) (bool, error)",
+			wantType: "func(string, ...int) (bool, error)",
+		},
+		{
+			name:     "7. channel type",
+			typ:      "<-chan int",
+			wantType: "<-chan int",
+		},
+		{
+			name:        "8. generic type",
+			typ:         "github.com/acme/app/pagination.Page[github.com/acme/app/models.User]",
+			wantType:    "pagination.Page[models.User]",
+			wantImports: []string{"github.com/acme/app/models", "github.com/acme/app/pagination"},
+		},
+		{
+			name:    "9. not a type",
+			typ:     "1 + 2",
+			wantErr: true,
+		},
+		{
+			name:    "10. packages with the same name",
+			typ:     "map[github.com/a/models.ID]github.com/b/models.User",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotType, gotImports, err := parseTypeExpr(tt.typ)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTypeExpr() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if gotType != tt.wantType {
+				t.Errorf("parseTypeExpr() type = %v, want %v", gotType, tt.wantType)
+			}
+
+			if len(gotImports) != 0 || len(tt.wantImports) != 0 {
+				if !reflect.DeepEqual(gotImports, tt.wantImports) {
+					t.Errorf("parseTypeExpr() imports = %v, want %v", gotImports, tt.wantImports)
+				}
+			}
+		})
+	}
+}
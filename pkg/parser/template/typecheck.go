@@ -0,0 +1,533 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/nxtcoder17/htmlc/pkg/funcs"
+)
+
+// Type checking
+//
+// After a struct is generated from a template, every action in the template is checked against the struct's
+// field types, so that mistakes like these fail at generate time, and not at render time
+//   - a field, or method that does not exist, i.e. {{ .user.Nmae }} for `@param user models.User`
+//   - range over a value, that is not iterable, i.e. {{ range .count }} for `@param count int`
+//   - comparison of mismatched types, i.e. {{ if eq .count "1" }} for `@param count int`
+//
+// Checks are best effort, a value whose type is not known (i.e. `any`, or from a package that could not be
+// loaded), is not checked.
+
+var (
+	fieldTypesFset     = token.NewFileSet()
+	fieldTypesImporter types.Importer
+	fieldTypesMu       sync.Mutex
+)
+
+// fieldTypes resolves go types of struct fields, a field whose type could not be resolved is nil
+func fieldTypes(s Struct) map[string]types.Type {
+	result := make(map[string]types.Type, len(s.Fields))
+
+	src := new(strings.Builder)
+	src.WriteString("package htmlc\n\n")
+	for _, pkg := range s.Imports {
+		fmt.Fprintf(src, "import %s %q\n", packageName(pkg), pkg)
+	}
+
+	for _, f := range s.Fields {
+		if len(f.Enum) > 0 {
+			fmt.Fprintf(src, "type %s string\n", f.Type)
+		}
+	}
+
+	fmt.Fprintf(src, "type %s struct {\n", s.Name)
+	for _, f := range s.Fields {
+		fmt.Fprintf(src, "%s %s\n", f.Name, f.Type)
+	}
+	src.WriteString("}\n")
+
+	fieldTypesMu.Lock()
+	defer fieldTypesMu.Unlock()
+
+	if fieldTypesImporter == nil {
+		// INFO: source importer type checks packages from their source, as compiled export data is not always available
+		fieldTypesImporter = importer.ForCompiler(fieldTypesFset, "source", nil)
+	}
+
+	file, err := parser.ParseFile(fieldTypesFset, "htmlc.go", src.String(), 0)
+	if err != nil {
+		return result
+	}
+
+	conf := types.Config{
+		Importer: fieldTypesImporter,
+		// INFO: errors (i.e. an unused import, or a package that could not be loaded) only make those fields unknown
+		Error: func(error) {},
+	}
+
+	pkg, _ := conf.Check("htmlc", fieldTypesFset, []*ast.File{file}, nil)
+	if pkg == nil {
+		return result
+	}
+
+	obj := pkg.Scope().Lookup(s.Name)
+	if obj == nil {
+		return result
+	}
+
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return result
+	}
+
+	for i, f := range s.Fields {
+		if i >= st.NumFields() {
+			break
+		}
+		if typ := st.Field(i).Type(); isKnownType(typ) {
+			result[f.JsonName] = typ
+		}
+	}
+
+	return result
+}
+
+// isKnownType reports whether typ is fully resolved, and is not an empty interface
+func isKnownType(typ types.Type) bool {
+	if typ == nil {
+		return false
+	}
+
+	known := true
+	var walk func(t types.Type)
+	walk = func(t types.Type) {
+		switch t := t.(type) {
+		case *types.Basic:
+			if t.Kind() == types.Invalid {
+				known = false
+			}
+		case *types.Pointer:
+			walk(t.Elem())
+		case *types.Slice:
+			walk(t.Elem())
+		case *types.Array:
+			walk(t.Elem())
+		case *types.Map:
+			walk(t.Key())
+			walk(t.Elem())
+		case *types.Chan:
+			walk(t.Elem())
+		}
+	}
+	walk(typ)
+
+	if iface, ok := typ.Underlying().(*types.Interface); ok && iface.Empty() {
+		return false
+	}
+
+	return known
+}
+
+// typeChecker walks a template, keeping track of the type of dot and variables, a nil type is unknown
+type typeChecker struct {
+	t      *template.Template
+	fields map[string]types.Type
+	errs   []error
+
+	// visiting guards against templates including themselves
+	visiting map[string]bool
+}
+
+// rootType marks dot, being the template data, whose fields are looked up by their json name
+type rootType struct{}
+
+func (rootType) Underlying() types.Type { return rootType{} }
+func (rootType) String() string         { return "template data" }
+
+type tcScope struct {
+	dot  types.Type
+	vars map[string]types.Type
+}
+
+func (sc tcScope) with(dot types.Type) tcScope {
+	vars := make(map[string]types.Type, len(sc.vars))
+	for k, v := range sc.vars {
+		vars[k] = v
+	}
+	return tcScope{dot: dot, vars: vars}
+}
+
+// typeCheck checks template t, against field types of struct s
+func typeCheck(t *template.Template, s Struct) error {
+	if t.Tree == nil {
+		return nil
+	}
+
+	tc := &typeChecker{t: t, fields: fieldTypes(s), visiting: map[string]bool{t.Name(): true}}
+
+	root := rootType{}
+	tc.walk(t.Root, tcScope{dot: root, vars: map[string]types.Type{"$": root}})
+
+	return errors.Join(tc.errs...)
+}
+
+func (tc *typeChecker) errorf(n parse.Node, format string, args ...any) {
+	location, context := tc.t.ErrorContext(n)
+	tc.errs = append(tc.errs, fmt.Errorf("template: %s: <%s>: %s", location, context, fmt.Sprintf(format, args...)))
+}
+
+func typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string { return p.Name() })
+}
+
+// fieldType returns the type of field (or method) name, on a value of type t
+func (tc *typeChecker) fieldType(n parse.Node, t types.Type, name string) types.Type {
+	if t == nil {
+		return nil
+	}
+
+	if _, ok := t.(rootType); ok {
+		return tc.fields[name]
+	}
+
+	if iface, ok := t.Underlying().(*types.Interface); ok && iface.Empty() {
+		return nil
+	}
+
+	obj, _, _ := types.LookupFieldOrMethod(t, true, nil, name)
+	if obj == nil {
+		under := t
+		if p, ok := t.Underlying().(*types.Pointer); ok {
+			under = p.Elem()
+		}
+
+		switch u := under.Underlying().(type) {
+		case *types.Map:
+			return u.Elem()
+		case *types.Interface:
+			// INFO: value could be of any type, implementing the interface
+			return nil
+		case *types.Struct:
+			// INFO: inferred nested structs are looked up by their json name
+			for i := 0; i < u.NumFields(); i++ {
+				if u.Tag(i) == fmt.Sprintf(`json:"%s"`, name) {
+					return u.Field(i).Type()
+				}
+			}
+		}
+
+		tc.errorf(n, "can't evaluate field %s in type %s", name, typeString(t))
+		return nil
+	}
+
+	if !obj.Exported() {
+		tc.errorf(n, "%s is an unexported field, or method of type %s", name, typeString(t))
+		return nil
+	}
+
+	switch obj := obj.(type) {
+	case *types.Var:
+		return obj.Type()
+	case *types.Func:
+		sig := obj.Type().(*types.Signature)
+		if sig.Results().Len() == 0 {
+			return nil
+		}
+		return sig.Results().At(0).Type()
+	}
+
+	return nil
+}
+
+func (tc *typeChecker) fieldChainType(n parse.Node, t types.Type, idents []string) types.Type {
+	for _, ident := range idents {
+		t = tc.fieldType(n, t, ident)
+	}
+	return t
+}
+
+// elemType returns type of the elements, while ranging over a value of type t
+func (tc *typeChecker) elemType(n parse.Node, t types.Type) types.Type {
+	if t == nil {
+		return nil
+	}
+
+	if _, ok := t.(rootType); ok {
+		return nil
+	}
+
+	under := t.Underlying()
+	if p, ok := under.(*types.Pointer); ok {
+		if arr, ok := p.Elem().Underlying().(*types.Array); ok {
+			return arr.Elem()
+		}
+	}
+
+	switch u := under.(type) {
+	case *types.Slice:
+		return u.Elem()
+	case *types.Array:
+		return u.Elem()
+	case *types.Map:
+		return u.Elem()
+	case *types.Chan:
+		return u.Elem()
+	case *types.Signature:
+		// INFO: range over an iterator function
+		return nil
+	case *types.Interface:
+		return nil
+	case *types.Basic:
+		if u.Info()&types.IsInteger != 0 {
+			return u
+		}
+	}
+
+	tc.errorf(n, "range can't iterate over %s", typeString(t))
+	return nil
+}
+
+// argType returns the type of a command argument
+func (tc *typeChecker) argType(n parse.Node, sc tcScope) types.Type {
+	switch node := n.(type) {
+	case *parse.DotNode:
+		return sc.dot
+	case *parse.FieldNode:
+		return tc.fieldChainType(node, sc.dot, node.Ident)
+	case *parse.VariableNode:
+		return tc.fieldChainType(node, sc.vars[node.Ident[0]], node.Ident[1:])
+	case *parse.ChainNode:
+		return tc.fieldChainType(node, tc.argType(node.Node, sc), node.Field)
+	case *parse.PipeNode:
+		return tc.pipeType(node, sc)
+	case *parse.StringNode:
+		return types.Typ[types.UntypedString]
+	case *parse.NumberNode:
+		switch {
+		case node.IsInt:
+			return types.Typ[types.UntypedInt]
+		case node.IsFloat:
+			return types.Typ[types.UntypedFloat]
+		}
+	case *parse.BoolNode:
+		return types.Typ[types.UntypedBool]
+	}
+	return nil
+}
+
+// pipeType checks a pipeline, and returns the type of its value
+func (tc *typeChecker) pipeType(pipe *parse.PipeNode, sc tcScope) types.Type {
+	if pipe == nil {
+		return nil
+	}
+
+	var result types.Type
+	for i, c := range pipe.Cmds {
+		result = tc.cmdType(c, sc, i > 0, result)
+	}
+
+	for _, v := range pipe.Decl {
+		sc.vars[v.Ident[0]] = result
+	}
+
+	return result
+}
+
+func (tc *typeChecker) cmdType(c *parse.CommandNode, sc tcScope, piped bool, pipedType types.Type) types.Type {
+	if len(c.Args) == 0 {
+		return nil
+	}
+
+	ident, ok := c.Args[0].(*parse.IdentifierNode)
+	if !ok {
+		for _, arg := range c.Args[1:] {
+			tc.argType(arg, sc)
+		}
+		return tc.argType(c.Args[0], sc)
+	}
+
+	args := make([]types.Type, 0, len(c.Args))
+	for _, arg := range c.Args[1:] {
+		args = append(args, tc.argType(arg, sc))
+	}
+	if piped {
+		args = append(args, pipedType)
+	}
+
+	switch ident.Ident {
+	case "eq", "ne", "lt", "le", "gt", "ge":
+		tc.checkComparison(c, ident.Ident, args)
+		return types.Typ[types.Bool]
+	case "not":
+		return types.Typ[types.Bool]
+	case "len":
+		return types.Typ[types.Int]
+	case "print", "printf", "println", "html", "js", "urlquery":
+		return types.Typ[types.String]
+	case "index":
+		if len(args) == 0 {
+			return nil
+		}
+		t := args[0]
+		for range args[1:] {
+			if t == nil {
+				return nil
+			}
+			if _, ok := t.(rootType); ok {
+				return nil
+			}
+			switch u := t.Underlying().(type) {
+			case *types.Slice:
+				t = u.Elem()
+			case *types.Array:
+				t = u.Elem()
+			case *types.Map:
+				t = u.Elem()
+			default:
+				return nil
+			}
+		}
+		return t
+	}
+
+	return funcResultType(ident.Ident)
+}
+
+// funcResultType returns the result type of a function from [funcs.FuncMap], when it is a basic type
+func funcResultType(name string) types.Type {
+	f, ok := funcs.FuncMap()[name]
+	if !ok {
+		return nil
+	}
+
+	ft := reflect.TypeOf(f)
+	if ft.Kind() != reflect.Func || ft.NumOut() == 0 {
+		return nil
+	}
+
+	switch ft.Out(0).Kind() {
+	case reflect.String:
+		return types.Typ[types.String]
+	case reflect.Bool:
+		return types.Typ[types.Bool]
+	case reflect.Int:
+		return types.Typ[types.Int]
+	}
+	return nil
+}
+
+// comparisonKind is the kind of a basic type, as compared by template's eq, i.e. int and int64 are comparable
+func comparisonKind(t types.Type) string {
+	if t == nil {
+		return ""
+	}
+	if _, ok := t.(rootType); ok {
+		return ""
+	}
+
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return ""
+	}
+
+	switch info := b.Info(); {
+	case info&types.IsBoolean != 0:
+		return "bool"
+	case info&types.IsInteger != 0:
+		return "integer"
+	case info&types.IsFloat != 0:
+		return "float"
+	case info&types.IsComplex != 0:
+		return "complex"
+	case info&types.IsString != 0:
+		return "string"
+	}
+	return ""
+}
+
+func (tc *typeChecker) checkComparison(n parse.Node, fn string, args []types.Type) {
+	if len(args) < 2 {
+		return
+	}
+
+	first := comparisonKind(args[0])
+	if first == "" {
+		return
+	}
+
+	for _, arg := range args[1:] {
+		if kind := comparisonKind(arg); kind != "" && kind != first {
+			tc.errorf(n, "incompatible types for comparison, in %s (%s and %s)", fn, typeString(args[0]), typeString(arg))
+		}
+	}
+}
+
+func (tc *typeChecker) walk(n parse.Node, sc tcScope) {
+	switch node := n.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, c := range node.Nodes {
+			tc.walk(c, sc)
+		}
+	case *parse.ActionNode:
+		if len(node.Pipe.Cmds) > 0 && len(node.Pipe.Cmds[0].Args) > 0 && node.Pipe.Cmds[0].Args[0].String() == paramLabel {
+			return
+		}
+		tc.pipeType(node.Pipe, sc)
+	case *parse.IfNode:
+		tc.pipeType(node.Pipe, sc)
+		tc.walk(node.List, sc.with(sc.dot))
+		tc.walk(node.ElseList, sc.with(sc.dot))
+	case *parse.WithNode:
+		inner := sc.with(sc.dot)
+		dot := tc.pipeType(node.Pipe, inner)
+		inner.dot = dot
+		tc.walk(node.List, inner)
+		tc.walk(node.ElseList, sc.with(sc.dot))
+	case *parse.RangeNode:
+		inner := sc.with(sc.dot)
+		t := tc.pipeType(&parse.PipeNode{Cmds: node.Pipe.Cmds}, inner)
+		elem := tc.elemType(node, t)
+
+		switch len(node.Pipe.Decl) {
+		case 1:
+			inner.vars[node.Pipe.Decl[0].Ident[0]] = elem
+		case 2:
+			inner.vars[node.Pipe.Decl[0].Ident[0]] = nil
+			inner.vars[node.Pipe.Decl[1].Ident[0]] = elem
+		}
+
+		inner.dot = elem
+		tc.walk(node.List, inner)
+		tc.walk(node.ElseList, sc.with(sc.dot))
+	case *parse.TemplateNode:
+		dot := tc.pipeType(node.Pipe, sc)
+
+		included := tc.t.Lookup(node.Name)
+		if included == nil || included.Tree == nil || tc.visiting[node.Name] {
+			return
+		}
+
+		// INFO: only templates, given typed data are checked here, as those with template data are checked on their own
+		if _, ok := dot.(rootType); ok || dot == nil {
+			return
+		}
+
+		tc.visiting[node.Name] = true
+		defer delete(tc.visiting, node.Name)
+
+		tc.walk(included.Root, tcScope{dot: dot, vars: map[string]types.Type{"$": dot}})
+	}
+}
@@ -0,0 +1,92 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_typeCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    string
+		wantErr string
+	}{
+		{
+			name: "1. valid template",
+			tmpl: /*gotmpl*/ `
+{{- /* @param user *net/url.Userinfo */}}
+{{- /* @param count int */}}
+{{- if eq .count 1 }}{{ .user.Username }}{{ end }}
+`,
+		},
+		{
+			name: "2. unknown field on a standard library type",
+			tmpl: /*gotmpl*/ `
+{{- /* @param user *net/url.Userinfo */}}
+{{ .user.Email }}
+`,
+			wantErr: "can't evaluate field Email in type *url.Userinfo",
+		},
+		{
+			name: "3. range over a string",
+			tmpl: /*gotmpl*/ `
+{{- /* @param name string */}}
+{{ range .name }}{{ . }}{{ end }}
+`,
+			wantErr: "range can't iterate over string",
+		},
+		{
+			name: "4. comparison of incompatible types",
+			tmpl: /*gotmpl*/ `
+{{- /* @param count int */}}
+{{ if eq .count "1" }}one{{ end }}
+`,
+			wantErr: "incompatible types for comparison",
+		},
+		{
+			name: "5. fields of untyped params are not checked",
+			tmpl: /*gotmpl*/ `
+{{- /* @param user any */}}
+{{ .user.Email }} {{ range .user.Items }}{{ .Name }}{{ end }}
+`,
+		},
+		{
+			name: "6. fields of range elements",
+			tmpl: /*gotmpl*/ `
+{{- /* @param dates []time.Time */}}
+{{ range $i, $d := .dates }}{{ $d.Year }}{{ end }}
+{{ range .dates }}{{ .Nope }}{{ end }}
+`,
+			wantErr: "can't evaluate field Nope in type time.Time",
+		},
+		{
+			name: "7. result of a template function",
+			tmpl: /*gotmpl*/ `
+{{- /* @param name string */}}
+{{ if eq (upper .name) 1 }}{{ .name | title }}{{ end }}
+`,
+			wantErr: "incompatible types for comparison",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp, err := NewFileParser(tt.tmpl, defaultStructName)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, _, _, err = fp.Parse()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Parse() unexpected error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want error containing (%s)", err, tt.wantErr)
+			}
+		})
+	}
+}
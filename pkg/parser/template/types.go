@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strconv"
 	"strings"
 	"text/template"
+	"unicode"
 )
 
 type Struct struct {
@@ -14,6 +16,40 @@ type Struct struct {
 	Imports      []string
 	Fields       []StructField
 	FromTemplate string
+
+	// UsesProps is true, when template renders unknown attributes with `{{.props}}`
+	UsesProps bool
+
+	// Key (for components) is the name, component is looked up with, i.e. ui:button for <ui:Button>, lowercased Name when empty
+	Key string
+}
+
+// LookupKey returns the name, struct's component is looked up with, i.e. ui:button for <ui:Button>
+func (st Struct) LookupKey() string {
+	if st.Key != "" {
+		return st.Key
+	}
+	return strings.ToLower(st.Name)
+}
+
+// HasDefaults reports whether any of the fields has a default value
+func (st Struct) HasDefaults() bool {
+	for _, f := range st.Fields {
+		if f.Default != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasEnums reports whether any of the fields is an enum
+func (st Struct) HasEnums() bool {
+	for _, f := range st.Fields {
+		if len(f.Enum) > 0 {
+			return true
+		}
+	}
+	return false
 }
 
 func (st *Struct) String() (string, error) {
@@ -47,6 +83,9 @@ func (st *Struct) String() (string, error) {
 {{- end}}
 type {{.Name}} struct {
   {{- range $v := .Fields }}
+  {{- if $v.Doc }}
+  // {{ $v.Doc }}
+  {{- end }}
   {{ $v.Name | titlecase }} {{ $v.Type }}
   {{- end }}
 }
@@ -69,6 +108,187 @@ type StructField struct {
 	Package  *string
 	JsonName string
 	Tag      string
+	Required bool
+
+	// Doc is the description of a param, i.e. `visible label text` for `@param label string -- visible label text`
+	Doc string
+
+	// Default is the default value (a go literal, i.e. "md", 10, true), used when the attribute is missing
+	Default string
+
+	// Enum is the closed set of values, for a param declared as `"a"|"b"`, its Type is a generated string type
+	Enum []EnumValue
+
+	// Fields are the nested fields, inferred from dotted field access (i.e. .User.Address.City), they make
+	// the field a nested struct, unless it has a `@param` type
+	Fields []StructField
+
+	// Slice is true, when template ranges over the field, and uses fields of its elements
+	Slice bool
+}
+
+// fieldFromPath returns the field for a dotted field access, i.e. User with nested fields Address.City, for .User.Address.City.
+// An element segment in the path (i.e. Items, [], Name for .Name inside {{ range .Items }}), makes the field a slice
+func fieldFromPath(idents []string) StructField {
+	sf := toStructField(idents[0], "any")
+	nestPath(&sf, idents[1:])
+	return sf
+}
+
+func nestPath(sf *StructField, idents []string) {
+	if len(idents) > 0 && idents[0] == elemSegment {
+		sf.Slice = true
+		idents = idents[1:]
+	}
+
+	// INFO: slices of slices are not inferred
+	if len(idents) == 0 || idents[0] == elemSegment {
+		return
+	}
+
+	f := StructField{
+		Name:     toFieldName(idents[0]),
+		Type:     "any",
+		JsonName: idents[0],
+		Tag:      fmt.Sprint("`", fmt.Sprintf(`json:"%s"`, idents[0]), "`"),
+	}
+	nestPath(&f, idents[1:])
+	sf.Fields = []StructField{f}
+}
+
+// inferredType returns the type of field, inferred from its nested fields
+func inferredType(sf StructField) string {
+	if len(sf.Fields) == 0 {
+		return sf.Type
+	}
+
+	if sf.Slice {
+		return "[]" + nestedStructType(sf.Fields)
+	}
+	return nestedStructType(sf.Fields)
+}
+
+// mergeFields merges fields b into a, fields in both of them have their nested fields merged
+func mergeFields(a []StructField, b []StructField) []StructField {
+	for _, f := range b {
+		idx := slices.IndexFunc(a, func(sf StructField) bool { return sf.Name == f.Name })
+		if idx == -1 {
+			a = append(a, f)
+			continue
+		}
+		a[idx].Fields = mergeFields(a[idx].Fields, f.Fields)
+		a[idx].Slice = a[idx].Slice || f.Slice
+	}
+	return a
+}
+
+// nestedStructType renders an anonymous struct type, with fields
+func nestedStructType(fields []StructField) string {
+	sb := new(strings.Builder)
+	sb.WriteString("struct {\n")
+	for _, f := range fields {
+		fmt.Fprintf(sb, "%s %s %s\n", f.Name, inferredType(f), f.Tag)
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// EnumValue is one of the values of an enum param, and its generated constant name
+type EnumValue struct {
+	Name  string
+	Value string
+}
+
+// EnumValues returns the values, an enum field allows
+func (sf StructField) EnumValues() []string {
+	values := make([]string, 0, len(sf.Enum))
+	for _, e := range sf.Enum {
+		values = append(values, e.Value)
+	}
+	return values
+}
+
+func isEnumType(typ string) bool {
+	return strings.HasPrefix(typ, `"`)
+}
+
+// parseEnum parses an enum type, i.e. `"primary"|"secondary"`, constants are named with typeName as prefix
+func parseEnum(typeName string, typ string) ([]EnumValue, error) {
+	names := make(map[string]string)
+
+	var result []EnumValue
+	for _, item := range strings.Split(typ, "|") {
+		v, err := strconv.Unquote(strings.TrimSpace(item))
+		if err != nil || v == "" {
+			return nil, fmt.Errorf("invalid enum value (%s), must be a non-empty double quoted string", strings.TrimSpace(item))
+		}
+
+		name := typeName + enumConstName(v)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("enum values (%s) and (%s), have the same constant name (%s)", other, v, name)
+		}
+		names[name] = v
+
+		result = append(result, EnumValue{Name: name, Value: v})
+	}
+
+	return result, nil
+}
+
+// enumConstName turns an enum value into an identifier suffix, i.e. dark-blue into DarkBlue
+func enumConstName(v string) string {
+	res := new(strings.Builder)
+	upper := true
+	for _, r := range v {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		res.WriteRune(r)
+	}
+	return res.String()
+}
+
+// DefaultValue parses field's default value, it is nil when field has no default
+func (sf StructField) DefaultValue() (any, error) {
+	if sf.Default == "" {
+		return nil, nil
+	}
+
+	if len(sf.Enum) > 0 {
+		v, err := strconv.Unquote(sf.Default)
+		if err != nil || !slices.Contains(sf.EnumValues(), v) {
+			return nil, fmt.Errorf("invalid default value (%s), must be one of (%s)", sf.Default, strings.Join(sf.EnumValues(), ", "))
+		}
+		return v, nil
+	}
+
+	var v any
+	var err error
+	switch strings.TrimPrefix(sf.Type, "*") {
+	case "string":
+		v, err = strconv.Unquote(sf.Default)
+	case "int", "int8", "int16", "int32", "int64":
+		v, err = strconv.ParseInt(sf.Default, 10, 64)
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		v, err = strconv.ParseUint(sf.Default, 10, 64)
+	case "float32", "float64":
+		v, err = strconv.ParseFloat(sf.Default, 64)
+	case "bool":
+		v, err = strconv.ParseBool(sf.Default)
+	default:
+		return nil, fmt.Errorf("default values are only supported for string, numeric and bool types, got (%s)", sf.Type)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid default value (%s) for type (%s)", sf.Default, sf.Type)
+	}
+
+	return v, nil
 }
 
 func toFieldName(str string) string {
@@ -99,6 +319,7 @@ func toStructField(varName, varType string) StructField {
 	fieldName := toFieldName(varName)
 
 	var tag, jsonName string
+	required := false
 	switch {
 	case strings.HasSuffix(varName, "?"):
 		// Optional Variable
@@ -107,6 +328,7 @@ func toStructField(varName, varType string) StructField {
 	default:
 		jsonName = varName
 		tag = fmt.Sprint("`", fmt.Sprintf(`json:"%s" validate:"required"`, jsonName), "`")
+		required = true
 	}
 
 	return StructField{
@@ -114,6 +336,7 @@ func toStructField(varName, varType string) StructField {
 		Type:     varType,
 		Tag:      tag,
 		JsonName: jsonName,
+		Required: required,
 	}
 }
 
@@ -124,7 +347,7 @@ func generateStructName(str string) string {
 			res.WriteString(strings.ToUpper(string(str[i])))
 			continue
 		}
-		if str[i] == '/' || str[i] == '-' || str[i] == '.' {
+		if str[i] == '/' || str[i] == '-' || str[i] == '.' || str[i] == ':' {
 			i += 1
 			res.WriteString(strings.ToUpper(string(str[i])))
 			continue
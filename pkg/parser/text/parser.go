@@ -0,0 +1,222 @@
+package text
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/nxtcoder17/htmlc/pkg/funcs"
+	template_parser "github.com/nxtcoder17/htmlc/pkg/parser/template"
+	"github.com/nxtcoder17/htmlc/pkg/types"
+)
+
+// Text pages (i.e. email bodies, config files, markdown) use components with a `component` action, in place of
+// html tags, with attributes as key, value pairs
+//
+//	{{ component "Greeting" "name" "John" "count" 2 }}
+//
+// components are expanded before the page is executed, so attribute values must be literals, and not pipelines
+// (i.e. .count), that could only be evaluated with page's data
+
+// Component is a component, that renders its template, with attributes it is built with
+type Component interface {
+	Render(w io.Writer) error
+}
+
+// ComponentSource is implemented by components, that know where they are defined
+type ComponentSource interface {
+	Source() string
+}
+
+type Params struct {
+	// FileName is the name of Input, used in error messages
+	FileName     string
+	Input        io.Reader
+	Output       io.Writer
+	GetComponent func(name string, attrs map[string]any) (Component, error)
+
+	// Delims are the go template delimiters, pages use, empty ones are the default `{{` and `}}`
+	Delims types.Delims
+}
+
+// Error is an error, encountered while expanding a `component` action
+type Error struct {
+	// File is the page file, where the component is used
+	File string
+
+	// Line, and Col point to the `component` action in File
+	Line int
+	Col  int
+
+	// Name is the component name
+	Name string
+
+	// Source describes where the component is defined, when known
+	Source string
+
+	Err error
+}
+
+func (e *Error) Error() string {
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "%s:%d:%d: component %q", e.File, e.Line, e.Col, e.Name)
+	if e.Source != "" {
+		fmt.Fprintf(sb, " (%s)", e.Source)
+	}
+
+	fmt.Fprintf(sb, ": %s", e.Err)
+	return sb.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// componentActionRe returns the regexp, for `component` actions using delims, its groups are
+//  1. left trim marker
+//  2. component name
+//  3. attributes
+//  4. right trim marker
+func componentActionRe(delims types.Delims) *regexp.Regexp {
+	d := delims.OrDefault()
+	return regexp.MustCompile(
+		regexp.QuoteMeta(d.Left) + `(-?)\s*component\s+"([^"]+)"` +
+			// attributes, quoted strings could have delimiters in them
+			`((?:[^"]|"(?:[^"\\]|\\.)*")*?)` +
+			`\s*(-?)` + regexp.QuoteMeta(d.Right),
+	)
+}
+
+// Parse expands all the `component` actions in a text page
+func Parse(p Params) error {
+	b, err := io.ReadAll(p.Input)
+	if err != nil {
+		return err
+	}
+
+	src := string(b)
+	d := p.Delims.OrDefault()
+
+	result := new(strings.Builder)
+	var errs []error
+
+	last := 0
+	for _, m := range componentActionRe(p.Delims).FindAllStringSubmatchIndex(src, -1) {
+		before := src[last:m[0]]
+		if m[3] > m[2] {
+			// INFO: {{- component ... }} trims spaces before it, like any other action
+			before = strings.TrimRightFunc(before, isSpace)
+		}
+		result.WriteString(before)
+
+		last = m[1]
+		if m[9] > m[8] {
+			// INFO: {{ component ... -}} trims spaces after it
+			last = m[1] + len(src[m[1]:]) - len(strings.TrimLeftFunc(src[m[1]:], isSpace))
+		}
+
+		name := src[m[4]:m[5]]
+		line := strings.Count(src[:m[0]], "\n") + 1
+		col := m[0] - strings.LastIndex(src[:m[0]], "\n")
+
+		out, err := renderComponent(p, d, name, src[m[6]:m[7]])
+		if err != nil {
+			var source string
+			if se, ok := err.(*sourceError); ok {
+				source, err = se.source, se.err
+			}
+			errs = append(errs, &Error{File: p.FileName, Line: line, Col: col, Name: name, Source: source, Err: err})
+			continue
+		}
+		result.WriteString(out)
+	}
+	result.WriteString(src[last:])
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	_, err = io.WriteString(p.Output, result.String())
+	return err
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\r' || r == '\n'
+}
+
+// sourceError is an error, of a component that knows where it is defined
+type sourceError struct {
+	source string
+	err    error
+}
+
+func (e *sourceError) Error() string {
+	return e.err.Error()
+}
+
+func renderComponent(p Params, d types.Delims, name string, args string) (string, error) {
+	attrs, err := componentAttrs(d, name, args)
+	if err != nil {
+		return "", err
+	}
+
+	c, err := p.GetComponent(template_parser.LookupName(name), attrs)
+	if err != nil {
+		return "", err
+	}
+
+	b := new(strings.Builder)
+	if err := c.Render(b); err != nil {
+		if cs, ok := c.(ComponentSource); ok {
+			return "", &sourceError{source: cs.Source(), err: err}
+		}
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// componentAttrs parses attributes of a `component` action, that must have literal values
+func componentAttrs(d types.Delims, name string, args string) (map[string]any, error) {
+	funcMap := template.FuncMap(funcs.FuncMap())
+	funcMap["component"] = func(name string, attrs ...any) string { return "" }
+
+	action := fmt.Sprintf("%scomponent %q %s%s", d.Left, name, args, d.Right)
+	t, err := template.New("component").Delims(d.Left, d.Right).Funcs(funcMap).Parse(action)
+	if err != nil {
+		return nil, err
+	}
+
+	an, ok := t.Root.Nodes[0].(*parse.ActionNode)
+	if !ok || len(t.Root.Nodes) != 1 || len(an.Pipe.Cmds) != 1 || len(an.Pipe.Decl) != 0 {
+		return nil, fmt.Errorf("component action could not be used in a pipeline, or assigned to a variable")
+	}
+	nodes := an.Pipe.Cmds[0].Args[2:]
+
+	if len(nodes)%2 != 0 {
+		return nil, fmt.Errorf("attributes must be key, value pairs, i.e. \"name\" .name")
+	}
+
+	attrs := make(map[string]any, len(nodes)/2)
+	for i := 0; i < len(nodes); i += 2 {
+		key, ok := nodes[i].(*parse.StringNode)
+		if !ok {
+			return nil, fmt.Errorf("attribute name must be a quoted string, got (%s)", nodes[i])
+		}
+
+		switch v := nodes[i+1].(type) {
+		case *parse.StringNode:
+			attrs[key.Text] = v.Text
+		case *parse.NumberNode, *parse.BoolNode:
+			attrs[key.Text] = v.String()
+		default:
+			return nil, fmt.Errorf("attribute (%s) must be a string, number or boolean literal, got (%s), as components are expanded before the page is executed", key.Text, v)
+		}
+	}
+
+	return attrs, nil
+}
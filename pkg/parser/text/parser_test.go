@@ -0,0 +1,126 @@
+package text
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/nxtcoder17/htmlc/pkg/types"
+)
+
+// staticComponent renders its name, and attributes (sorted by key), i.e. Greeting(count=2,name=John)
+type staticComponent struct {
+	name  string
+	attrs map[string]any
+}
+
+func (c *staticComponent) Render(w io.Writer) error {
+	keys := make([]string, 0, len(c.attrs))
+	for k := range c.attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	items := make([]string, 0, len(keys))
+	for _, k := range keys {
+		items = append(items, fmt.Sprintf("%s=%v", k, c.attrs[k]))
+	}
+
+	_, err := fmt.Fprintf(w, "%s(%s)", c.name, strings.Join(items, ","))
+	return err
+}
+
+func getStaticComponent(name string, attrs map[string]any) (Component, error) {
+	if name == "unknown" {
+		return nil, fmt.Errorf("unknown component (%s)", name)
+	}
+	return &staticComponent{name: name, attrs: attrs}, nil
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		delims types.Delims
+
+		wantOutput string
+		wantErr    []string
+	}{
+		{
+			name:       "1. page without components, is kept as is",
+			input:      "Hi {{ .name }},\n<b>bye</b>\n",
+			wantOutput: "Hi {{ .name }},\n<b>bye</b>\n",
+		},
+		{
+			name:       "2. literal attributes",
+			input:      `Hi {{ component "Greeting" "name" "John" "count" 2 "admin" true }}!`,
+			wantOutput: `Hi greeting(admin=true,count=2,name=John)!`,
+		},
+		{
+			name:       "3. nested component names, and trim markers",
+			input:      "Hi\n  {{- component \"email/Greeting\" -}}  \n!",
+			wantOutput: "Hiemailgreeting()!",
+		},
+		{
+			name:       "4. custom delimiters",
+			input:      `[[ component "Greeting" "name" "John" "text" "{{ kept }}" ]] [[ .name ]] {{ kept }}`,
+			delims:     types.Delims{Left: "[[", Right: "]]"},
+			wantOutput: `greeting(name=John,text={{ kept }}) [[ .name ]] {{ kept }}`,
+		},
+		{
+			name:  "5. errors of all the components are reported",
+			input: "{{ component \"Unknown\" }}\n  {{ component \"Greeting\" \"name\" }}\n{{ component \"Greeting\" | printf \"%s\" }}\n{{ component \"Greeting\" \"name\" .user.name \"count\" (len .items) }}",
+			wantErr: []string{
+				`page.txt:1:1: component "Unknown": unknown component (unknown)`,
+				`page.txt:2:3: component "Greeting": attributes must be key, value pairs, i.e. "name" .name`,
+				`page.txt:3:1: component "Greeting": component action could not be used in a pipeline, or assigned to a variable`,
+				`page.txt:4:1: component "Greeting": attribute (name) must be a string, number or boolean literal, got (.user.name), as components are expanded before the page is executed`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			err := Parse(Params{
+				FileName:     "page.txt",
+				Input:        strings.NewReader(tt.input),
+				Output:       out,
+				GetComponent: getStaticComponent,
+				Delims:       tt.delims,
+			})
+
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatalf("Parse() expected errors, got none")
+				}
+
+				var got []string
+				for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+					var pe *Error
+					if !errors.As(e, &pe) {
+						t.Fatalf("expected error of type *Error, got %T", e)
+					}
+					got = append(got, e.Error())
+				}
+
+				if !reflect.DeepEqual(got, tt.wantErr) {
+					t.Errorf("errors did not match:\n\n\twant: %q\n\tgot: %q\n\n", tt.wantErr, got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("output did not match:\n\n\twant: %q\n\tgot: %q\n\n", tt.wantOutput, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package types
+
+// Delims are the action delimiters of go templates, i.e. `[[` and `]]`, empty ones are the default `{{` and `}}`
+type Delims struct {
+	Left  string
+	Right string
+}
+
+// OrDefault returns d, with empty delimiters replaced by the default ones
+func (d Delims) OrDefault() Delims {
+	if d.Left == "" {
+		d.Left = "{{"
+	}
+	if d.Right == "" {
+		d.Right = "}}"
+	}
+	return d
+}
+
+// IsDefault reports whether d are the default `{{` and `}}` delimiters
+func (d Delims) IsDefault() bool {
+	return d.OrDefault() == Delims{}.OrDefault()
+}
@@ -25,3 +25,16 @@ func NewSet[T comparable](items ...T) *Set[T] {
 
 	return s
 }
+
+func (s *Set[T]) Len() int {
+	return len(s.items)
+}
+
+// Items returns all items of the set, in no particular order
+func (s *Set[T]) Items() []T {
+	result := make([]T, 0, len(s.items))
+	for k := range s.items {
+		result = append(result, k)
+	}
+	return result
+}